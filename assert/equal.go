@@ -1,7 +1,6 @@
 package assert
 
 import (
-	"fmt"
 	"reflect"
 
 	"github.com/pkg/errors"
@@ -34,10 +33,10 @@ func Equal(q *query.Query, expected interface{}) Assertion {
 					return nil
 				}
 			}
-			return errors.Errorf(fmt.Sprintf("%s: expected %T (%+v) but got %T (%+v)", q.String(), expected, expected, v, v))
+			return queryErrorf(q, "expected %T (%+v) but got %T (%+v)", expected, expected, v, v)
 		}
 
-		return errors.Errorf(fmt.Sprintf("%s: expected %+v but got %+v", q.String(), expected, v))
+		return queryErrorf(q, "expected %+v but got %+v", expected, v)
 	})
 }
 
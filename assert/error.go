@@ -1,6 +1,11 @@
 package assert
 
-import "github.com/hashicorp/go-multierror"
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/zoncoen/query-go"
+)
 
 // Error is an error type to track multiple errors.
 type Error = multierror.Error
@@ -8,3 +13,27 @@ type Error = multierror.Error
 // AppendError is a helper function that will append more errors
 // onto an Error in order to create a larger multi-error.
 var AppendError = multierror.Append
+
+// QueryError represents an assertion error of the value extracted by the query.
+type QueryError struct {
+	// Query is the query string such as ".items[0].name".
+	Query string
+	Err   error
+}
+
+// Error implements error interface.
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Query, e.Err)
+}
+
+// Cause returns the underlying error.
+func (e *QueryError) Cause() error {
+	return e.Err
+}
+
+func queryErrorf(q *query.Query, format string, args ...interface{}) error {
+	return &QueryError{
+		Query: q.String(),
+		Err:   fmt.Errorf(format, args...),
+	}
+}
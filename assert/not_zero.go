@@ -3,7 +3,6 @@ package assert
 import (
 	"reflect"
 
-	"github.com/pkg/errors"
 	"github.com/zoncoen/query-go"
 )
 
@@ -11,7 +10,7 @@ import (
 func NotZero(q *query.Query) Assertion {
 	return assertFunc(q, func(v interface{}) error {
 		if v == nil || reflect.DeepEqual(v, reflect.Zero(reflect.TypeOf(v)).Interface()) {
-			return errors.Errorf("%s: expected not zero value", q.String())
+			return queryErrorf(q, "expected not zero value")
 		}
 		return nil
 	})
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+// runnerFlags represents the common flags to create a runner.
+type runnerFlags struct {
+	fs           *flag.FlagSet
+	configPath   *string
+	profile      *string
+	pluginDir    *string
+	tags         *string
+	run          *string
+	varsFiles    stringsFlag
+	secretsFiles stringsFlag
+
+	config *schema.Config
+}
+
+func addRunnerFlags(fs *flag.FlagSet) *runnerFlags {
+	f := &runnerFlags{
+		fs:         fs,
+		configPath: fs.String("config", "", "`path` of the project configuration file (default \""+schema.DefaultConfigFileName+"\" if exists)"),
+		profile:    fs.String("env", "", "`name` of the environment profile defined in the configuration file"),
+		pluginDir:  fs.String("plugin-dir", "", "root `directory` of the plugins"),
+		tags:       fs.String("tags", "", "select only the scenarios which match the tag `expression` such as \"smoke && !slow\""),
+		run:        fs.String("run", "", "select only the scenarios and steps whose \"file/scenario/step\" path match the regular `expression`"),
+	}
+	fs.Var(&f.varsFiles, "vars-file", "load the variables from the YAML, JSON or .env `file` (can be repeated)")
+	fs.Var(&f.secretsFiles, "secrets-file", "load the secrets which are masked in the logs from the YAML, JSON or .env `file` (can be repeated)")
+	return f
+}
+
+// loadConfig loads the project configuration after parsing flags.
+// The configuration is nil if the config flag is not specified and the default configuration file does not exist.
+func (f *runnerFlags) loadConfig() error {
+	cfg, err := loadConfig(*f.configPath, *f.profile)
+	if err != nil {
+		return err
+	}
+	if f.fs.NArg() == 0 && (cfg == nil || len(cfg.Scenarios) == 0) {
+		return errors.New("no scenario paths specified")
+	}
+	f.config = cfg
+	return nil
+}
+
+// options returns the runner options specified by the flags.
+func (f *runnerFlags) options() []func(*scenarigo.Runner) error {
+	var opts []func(*scenarigo.Runner) error
+	if f.config != nil {
+		opts = append(opts, scenarigo.WithConfig(f.config))
+	}
+	if f.fs.NArg() > 0 {
+		opts = append(opts, scenarigo.WithScenarios(f.fs.Args()...))
+	}
+	if *f.pluginDir != "" {
+		opts = append(opts, scenarigo.WithPluginDir(*f.pluginDir))
+	}
+	if *f.tags != "" {
+		opts = append(opts, scenarigo.WithTags(*f.tags))
+	}
+	if *f.run != "" {
+		opts = append(opts, scenarigo.WithRunPattern(*f.run))
+	}
+	if len(f.varsFiles) > 0 {
+		opts = append(opts, scenarigo.WithVarsFiles(f.varsFiles...))
+	}
+	if len(f.secretsFiles) > 0 {
+		opts = append(opts, scenarigo.WithSecretsFiles(f.secretsFiles...))
+	}
+	return opts
+}
+
+// stringsFlag is a flag which can be specified multiple times.
+type stringsFlag []string
+
+// String implements flag.Value interface.
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+// Set implements flag.Value interface.
+func (f *stringsFlag) Set(v string) error {
+	*f = append(*f, v)
+	return nil
+}
+
+// loadConfig loads the project configuration.
+// It returns nil without error if path is not specified and the default configuration file does not exist.
+func loadConfig(path, profile string) (*schema.Config, error) {
+	if path == "" {
+		if _, err := os.Stat(schema.DefaultConfigFileName); err != nil {
+			if profile != "" {
+				return nil, errors.Errorf(`failed to select profile "%s": no config`, profile)
+			}
+			return nil, nil
+		}
+		path = schema.DefaultConfigFileName
+	}
+	cfg, err := schema.LoadConfig(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to load config "%s"`, path)
+	}
+	if profile != "" {
+		return cfg.WithProfile(profile)
+	}
+	return cfg, nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	var found bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			found = true
+		}
+	})
+	return found
+}
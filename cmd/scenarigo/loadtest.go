@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/zoncoen/scenarigo"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+func loadtestCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: scenarigo loadtest [flags] [path ...]\n\nLoadtest runs the test scenarios found in the given files and directories repeatedly and reports the statistics of each step.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	rf := addRunnerFlags(fs)
+	concurrency := fs.Int("concurrency", 1, "`number` of workers which run the scenarios simultaneously")
+	duration := fs.Duration("duration", 0, "`duration` to keep running the scenarios such as \"1m\"")
+	iterations := fs.Int("iterations", 0, "total `number` of iterations which run all scenarios once")
+	timeout := fs.Duration("timeout", 0, "default timeout `duration` of each step such as \"30s\" (default no timeout)")
+	format := fs.String("format", schema.OutputFormatText, "output `format` of the statistics (text or json)")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if err := rf.loadConfig(); err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+	switch *format {
+	case schema.OutputFormatText, schema.OutputFormatJSON:
+	default:
+		fmt.Fprintf(stderr, "scenarigo: unknown output format %q\n", *format)
+		return exitError
+	}
+	if *concurrency < 1 {
+		fmt.Fprintf(stderr, "scenarigo: -concurrency must be greater than 0 but got %d\n", *concurrency)
+		return exitError
+	}
+
+	opts := rf.options()
+	if *timeout != 0 {
+		opts = append(opts, scenarigo.WithTimeout(*timeout))
+	}
+	r, err := scenarigo.NewRunner(opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+
+	var result *scenarigo.LoadTestResult
+	// the failures of the setup and teardown hooks are written to stderr
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result, err = r.LoadTest(context.New(rptr), scenarigo.LoadTestOptions{
+			Concurrency: *concurrency,
+			Duration:    *duration,
+			Iterations:  *iterations,
+		})
+	}, reporter.WithWriter(stderr))
+	if err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+
+	if *format == schema.OutputFormatJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(stderr, "scenarigo: failed to write the result: %s\n", err)
+			return exitError
+		}
+	} else {
+		printLoadTestResult(stdout, result)
+	}
+	if !ok || result.Failed() {
+		return exitFailed
+	}
+	return exitOK
+}
+
+func printLoadTestResult(w io.Writer, result *scenarigo.LoadTestResult) {
+	fmt.Fprintf(w, "iterations: %d (%d failed), duration: %s, throughput: %.2f/s\n\n", result.Iterations, result.Failures, result.Duration.Round(time.Millisecond), result.Throughput)
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "STEP\tCOUNT\tERRORS\tRPS\tMIN\tMEAN\tP50\tP90\tP95\tP99\tMAX")
+	for _, step := range result.Steps {
+		l := step.Latency
+		fmt.Fprintf(tw, "%s\t%d\t%d (%.2f%%)\t%.2f\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			step.Name, step.Count, step.Errors, step.ErrorRate*100, step.Throughput,
+			roundLatency(l.Min), roundLatency(l.Mean), roundLatency(l.P50), roundLatency(l.P90), roundLatency(l.P95), roundLatency(l.P99), roundLatency(l.Max),
+		)
+	}
+	tw.Flush()
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	return d.Round(time.Microsecond)
+}
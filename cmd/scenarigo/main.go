@@ -0,0 +1,88 @@
+// Command scenarigo executes test scenarios written in YAML.
+//
+// Usage:
+//
+//	scenarigo <command> [arguments]
+//
+// The commands are:
+//
+//	loadtest  runs test scenarios repeatedly and reports the statistics
+//	run       runs test scenarios
+//	schema    prints the JSON Schema of test scenario files
+//	validate  validates test scenarios without sending requests
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// exit codes
+const (
+	exitOK     = 0 // all tests passed
+	exitFailed = 1 // some tests failed
+	exitError  = 2 // invalid usage or internal error
+)
+
+// command represents a sub-command of scenarigo.
+type command struct {
+	usage string
+	run   func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]*command{
+	"loadtest": {
+		usage: "runs test scenarios repeatedly and reports the statistics",
+		run:   loadtestCommand,
+	},
+	"run": {
+		usage: "runs test scenarios",
+		run:   runCommand,
+	},
+	"schema": {
+		usage: "prints the JSON Schema of test scenario files",
+		run:   schemaCommand,
+	},
+	"validate": {
+		usage: "validates test scenarios without sending requests",
+		run:   validateCommand,
+	},
+}
+
+func main() {
+	os.Exit(execute(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func execute(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return exitError
+	}
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "scenarigo: unknown command %q\n", args[0])
+		printUsage(stderr)
+		return exitError
+	}
+	return cmd.run(args[1:], stdout, stderr)
+}
+
+func printUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprint(w, "Usage:\n\n\tscenarigo <command> [arguments]\n\nThe commands are:\n\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "\t%-10s %s\n", name, commands[name].usage)
+	}
+	fmt.Fprintln(w, "\nUse \"scenarigo <command> -h\" for more information about a command.")
+}
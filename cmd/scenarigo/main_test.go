@@ -0,0 +1,243 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/protocol"
+)
+
+type testProtocol struct{}
+
+func (p *testProtocol) Name() string { return "test" }
+
+func (p *testProtocol) UnmarshalRequest(f func(interface{}) error) (protocol.Invoker, error) {
+	var req testRequest
+	if err := f(&req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (p *testProtocol) UnmarshalExpect(f func(interface{}) error) (protocol.AssertionBuilder, error) {
+	return nil, nil
+}
+
+type testRequest struct {
+	Fail interface{} `yaml:"fail"`
+}
+
+func (r *testRequest) Invoke(ctx *context.Context) (*context.Context, interface{}, error) {
+	fail, err := ctx.ExecuteTemplate(r.Fail)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if fail == true {
+		return ctx, nil, errors.New("request failed")
+	}
+	return ctx, nil, nil
+}
+
+func TestMain(m *testing.M) {
+	protocol.Register(&testProtocol{})
+	os.Exit(m.Run())
+}
+
+func TestExecute(t *testing.T) {
+	tests := map[string]struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		"no arguments": {
+			code:   exitError,
+			stderr: "Usage:",
+		},
+		"help": {
+			args:   []string{"help"},
+			code:   exitOK,
+			stdout: "run ",
+		},
+		"unknown command": {
+			args:   []string{"unknown"},
+			code:   exitError,
+			stderr: `unknown command "unknown"`,
+		},
+		"run: pass": {
+			args:   []string{"run", "testdata/pass.yaml"},
+			code:   exitOK,
+			stdout: "--- PASS: testdata/pass.yaml",
+		},
+		"run: fail": {
+			args:   []string{"run", "testdata/pass.yaml", "testdata/fail.yaml"},
+			code:   exitFailed,
+			stdout: "--- FAIL: testdata/fail.yaml",
+		},
+		"run: tags": {
+			args:   []string{"run", "-tags", "smoke", "testdata/pass.yaml", "testdata/fail.yaml"},
+			code:   exitOK,
+			stdout: "--- PASS: testdata/pass.yaml",
+		},
+		"run: invalid tags": {
+			args:   []string{"run", "-tags", "smoke &&", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "invalid tag expression",
+		},
+		"run: run pattern": {
+			args:   []string{"run", "-run", "pass/ok", "testdata/pass.yaml", "testdata/fail.yaml"},
+			code:   exitOK,
+			stdout: "--- PASS: testdata/pass.yaml/pass/ok",
+		},
+		"run: json": {
+			args:   []string{"run", "-format", "json", "testdata/fail.yaml"},
+			code:   exitFailed,
+			stdout: `"status": "failed"`,
+		},
+		"run: unknown format": {
+			args:   []string{"run", "-format", "xml", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: `unknown output format "xml"`,
+		},
+		"validate: valid": {
+			args:   []string{"validate", "testdata/pass.yaml", "testdata/fail.yaml"},
+			code:   exitOK,
+			stdout: "ok",
+		},
+		"validate: invalid": {
+			args:   []string{"validate", "testdata/invalid.yaml"},
+			code:   exitFailed,
+			stdout: "testdata/invalid.yaml:7:3: invalid/typo: invalid request: \"{{vars.mesage}}\" refers undefined variable \"vars.mesage\"\n1 problems found",
+		},
+		"validate: no paths": {
+			args:   []string{"validate"},
+			code:   exitError,
+			stderr: "no scenario paths specified",
+		},
+		"loadtest: pass": {
+			args:   []string{"loadtest", "-iterations", "3", "-concurrency", "2", "testdata/pass.yaml"},
+			code:   exitOK,
+			stdout: "iterations: 3 (0 failed)",
+		},
+		"loadtest: fail": {
+			args:   []string{"loadtest", "-iterations", "2", "-format", "json", "testdata/fail.yaml"},
+			code:   exitFailed,
+			stdout: `"failures": 2`,
+		},
+		"loadtest: no limits": {
+			args:   []string{"loadtest", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "duration or iterations must be specified",
+		},
+		"schema": {
+			args:   []string{"schema"},
+			code:   exitOK,
+			stdout: `"$schema": "http://json-schema.org/draft-07/schema#"`,
+		},
+		"schema: unexpected arguments": {
+			args:   []string{"schema", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "unexpected arguments",
+		},
+		"run: no paths": {
+			args:   []string{"run"},
+			code:   exitError,
+			stderr: "no scenario paths specified",
+		},
+		"run: file not found": {
+			args:   []string{"run", "testdata/notfound.yaml"},
+			code:   exitError,
+			stderr: "notfound.yaml",
+		},
+		"run: config": {
+			args:   []string{"run", "-config", "testdata/config/scenarigo.yaml"},
+			code:   exitOK,
+			stdout: "--- PASS: testdata/config/vars.yaml",
+		},
+		"run: config with profile": {
+			args:   []string{"run", "-config", "testdata/config/scenarigo.yaml", "-env", "failure"},
+			code:   exitFailed,
+			stdout: "--- FAIL: testdata/config/vars.yaml",
+		},
+		"run: vars file": {
+			args:   []string{"run", "-config", "testdata/config/scenarigo.yaml", "-vars-file", "testdata/config/fail.json"},
+			code:   exitFailed,
+			stdout: "--- FAIL: testdata/config/vars.yaml",
+		},
+		"run: vars file not found": {
+			args:   []string{"run", "-vars-file", "testdata/notfound.env", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: `failed to load vars file "testdata/notfound.env"`,
+		},
+		"run: secrets file not found": {
+			args:   []string{"run", "-secrets-file", "testdata/notfound.env", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: `failed to load vars file "testdata/notfound.env"`,
+		},
+		"run: profile not found": {
+			args:   []string{"run", "-config", "testdata/config/scenarigo.yaml", "-env", "unknown"},
+			code:   exitError,
+			stderr: `profile "unknown" not found`,
+		},
+		"run: profile without config": {
+			args:   []string{"run", "-env", "failure", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "no config",
+		},
+		"run: invalid parallel": {
+			args:   []string{"run", "-parallel", "0", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "-parallel must be greater than 0",
+		},
+		"run: invalid timeout": {
+			args:   []string{"run", "-timeout", "-1s", "testdata/pass.yaml"},
+			code:   exitError,
+			stderr: "timeout must not be negative",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if got, expect := execute(test.args, &stdout, &stderr), test.code; got != expect {
+				t.Errorf("expected exit code %d but got %d\nstdout:\n%s\nstderr:\n%s", expect, got, stdout.String(), stderr.String())
+			}
+			if !strings.Contains(stdout.String(), test.stdout) {
+				t.Errorf("stdout does not contain %q:\n%s", test.stdout, stdout.String())
+			}
+			if !strings.Contains(stderr.String(), test.stderr) {
+				t.Errorf("stderr does not contain %q:\n%s", test.stderr, stderr.String())
+			}
+		})
+	}
+}
+
+func TestRunCommand_Output(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scenarigo")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "result.txt")
+	var stdout, stderr bytes.Buffer
+	if code := runCommand([]string{"-output", path, "testdata/pass.yaml"}, &stdout, &stderr); code != exitOK {
+		t.Fatalf("expected exit code %d but got %d: %s", exitOK, code, stderr.String())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("unexpected stdout: %s", stdout.String())
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(string(b), "--- PASS: testdata/pass.yaml") {
+		t.Errorf("unexpected output: %s", string(b))
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+
+	"github.com/zoncoen/scenarigo"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+func runCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: scenarigo run [flags] [path ...]\n\nRun runs the test scenarios found in the given files and directories.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	rf := addRunnerFlags(fs)
+	parallel := fs.Int("parallel", runtime.GOMAXPROCS(0), "maximum `number` of scenarios to run simultaneously")
+	timeout := fs.Duration("timeout", 0, "default timeout `duration` of each step such as \"30s\" (default no timeout)")
+	format := fs.String("format", schema.OutputFormatText, "output `format` of the test results (text or json)")
+	output := fs.String("output", "", "write the test results to the `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+
+	if err := rf.loadConfig(); err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+	cfg := rf.config
+	if cfg != nil && cfg.MaxParallel > 0 && !isFlagSet(fs, "parallel") {
+		*parallel = cfg.MaxParallel
+	}
+	if cfg != nil && cfg.Output.Format != "" && !isFlagSet(fs, "format") {
+		*format = cfg.Output.Format
+	}
+	switch *format {
+	case schema.OutputFormatText, schema.OutputFormatJSON:
+	default:
+		fmt.Fprintf(stderr, "scenarigo: unknown output format %q\n", *format)
+		return exitError
+	}
+	if *parallel < 1 {
+		fmt.Fprintf(stderr, "scenarigo: -parallel must be greater than 0 but got %d\n", *parallel)
+		return exitError
+	}
+
+	opts := append(rf.options(), scenarigo.WithMaxParallel(*parallel))
+	if *timeout != 0 {
+		opts = append(opts, scenarigo.WithTimeout(*timeout))
+	}
+	r, err := scenarigo.NewRunner(opts...)
+	if err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "scenarigo: failed to create output file: %s\n", err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+
+	rptrOpts := []reporter.Option{reporter.WithMaxParallel(*parallel)}
+	if *format == schema.OutputFormatText {
+		rptrOpts = append(rptrOpts, reporter.WithWriter(w))
+	}
+	var result *scenarigo.Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, rptrOpts...)
+	if *format == schema.OutputFormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(result); err != nil {
+			fmt.Fprintf(stderr, "scenarigo: failed to write the result: %s\n", err)
+			return exitError
+		}
+	}
+	if !ok {
+		return exitFailed
+	}
+	return exitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+func schemaCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: scenarigo schema [flags]\n\nSchema prints the JSON Schema of test scenario files for editors to complete and validate them.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	output := fs.String("output", "", "write the schema to the `file` instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "scenarigo: unexpected arguments: %v\n", fs.Args())
+		return exitError
+	}
+
+	w := stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(stderr, "scenarigo: failed to create output file: %s\n", err)
+			return exitError
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(schema.JSONSchema()); err != nil {
+		fmt.Fprintf(stderr, "scenarigo: failed to write the schema: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
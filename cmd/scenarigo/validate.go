@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/zoncoen/scenarigo"
+)
+
+func validateCommand(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: scenarigo validate [flags] [path ...]\n\nValidate checks the test scenarios found in the given files and directories without sending any requests.\n\nFlags:")
+		fs.PrintDefaults()
+	}
+	rf := addRunnerFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitError
+	}
+	if err := rf.loadConfig(); err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+	r, err := scenarigo.NewRunner(rf.options()...)
+	if err != nil {
+		fmt.Fprintf(stderr, "scenarigo: %s\n", err)
+		return exitError
+	}
+
+	if err := r.Validate(); err != nil {
+		errs := []error{err}
+		if merr, ok := err.(*multierror.Error); ok {
+			errs = merr.Errors
+		}
+		for _, err := range errs {
+			fmt.Fprintln(stdout, err)
+		}
+		fmt.Fprintf(stdout, "%d problems found\n", len(errs))
+		return exitFailed
+	}
+	fmt.Fprintln(stdout, "ok")
+	return exitOK
+}
@@ -0,0 +1,53 @@
+package scenarigo
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+// shouldRun evaluates the if condition of step.
+func shouldRun(ctx *context.Context, step *schema.Step) (bool, error) {
+	if step.If == "" {
+		return true, nil
+	}
+	return evalCondition(ctx, step.If)
+}
+
+// evalCondition executes the template cond and reports whether the result is truthy.
+func evalCondition(ctx *context.Context, cond string) (bool, error) {
+	x, err := ctx.ExecuteTemplate(cond)
+	if err != nil {
+		return false, err
+	}
+	return isTruthy(x), nil
+}
+
+// isTruthy reports whether v is regarded as true.
+// nil, false, zero numbers, empty collections, empty strings and the string "false" are false.
+// Strings are compared with "true" and "false" case-insensitively, and any other non-empty strings such as "0" are true.
+func isTruthy(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Bool:
+		return rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() != 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return rv.Uint() != 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() != 0
+	case reflect.String:
+		return rv.Len() > 0 && !strings.EqualFold(rv.String(), "false")
+	case reflect.Map, reflect.Slice, reflect.Array:
+		return rv.Len() > 0
+	case reflect.Ptr, reflect.Interface:
+		return !rv.IsNil()
+	}
+	return true
+}
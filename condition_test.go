@@ -0,0 +1,42 @@
+package scenarigo
+
+import "testing"
+
+func TestIsTruthy(t *testing.T) {
+	tests := map[string]struct {
+		v      interface{}
+		expect bool
+	}{
+		"nil":          {v: nil, expect: false},
+		"true":         {v: true, expect: true},
+		"false":        {v: false, expect: false},
+		"zero":         {v: 0, expect: false},
+		"non-zero":     {v: 1, expect: true},
+		"zero float":   {v: 0.0, expect: false},
+		"empty string": {v: "", expect: false},
+		"string":       {v: "enabled", expect: true},
+		`"false"`:      {v: "false", expect: false},
+		`"FALSE"`:      {v: "FALSE", expect: false},
+		`"False"`:      {v: "False", expect: false},
+		`"true"`:       {v: "true", expect: true},
+		`"TRUE"`:       {v: "TRUE", expect: true},
+		`"0"`:          {v: "0", expect: true},
+		`"f"`:          {v: "f", expect: true},
+		`"F"`:          {v: "F", expect: true},
+		`"no"`:         {v: "no", expect: true},
+		"empty map":    {v: map[string]interface{}{}, expect: false},
+		"map":          {v: map[string]interface{}{"a": 1}, expect: true},
+		"empty slice":  {v: []interface{}{}, expect: false},
+		"slice":        {v: []interface{}{1}, expect: true},
+		"nil pointer":  {v: (*int)(nil), expect: false},
+		"struct":       {v: struct{}{}, expect: true},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if got := isTruthy(test.v); got != test.expect {
+				t.Errorf("expect %t but got %t", test.expect, got)
+			}
+		})
+	}
+}
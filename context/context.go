@@ -6,16 +6,18 @@ import (
 	"path/filepath"
 	"plugin"
 	"testing"
+	"time"
 
 	"github.com/zoncoen/scenarigo/reporter"
 )
 
 type (
-	keyPluginDir struct{}
-	keyPlugins   struct{}
-	keyVars      struct{}
-	keyRequest   struct{}
-	keyResponse  struct{}
+	keyPluginDir   struct{}
+	keyStepTimeout struct{}
+	keyPlugins     struct{}
+	keyVars        struct{}
+	keyRequest     struct{}
+	keyResponse    struct{}
 )
 
 // Context represents a scenarigo context.
@@ -27,12 +29,12 @@ type Context struct {
 
 // New returns a new scenarigo context.
 func New(r reporter.Reporter) *Context {
-	return newContext(context.Background(), context.Background(), r)
+	return newContext(withDefaultMaskedFields(context.Background()), context.Background(), r)
 }
 
 // FromT creates a new context from t.
 func FromT(t *testing.T) *Context {
-	return newContext(context.Background(), context.Background(), reporter.FromT(t))
+	return newContext(withDefaultMaskedFields(context.Background()), context.Background(), reporter.FromT(t))
 }
 
 func newContext(ctx context.Context, reqCtx context.Context, r reporter.Reporter) *Context {
@@ -89,6 +91,25 @@ func (c *Context) PluginDir() string {
 	return ""
 }
 
+// WithStepTimeout returns a copy of c with the default timeout of steps.
+func (c *Context) WithStepTimeout(d time.Duration) *Context {
+	return newContext(
+		context.WithValue(c.ctx, keyStepTimeout{}, d),
+		c.reqCtx,
+		c.reporter,
+	)
+}
+
+// StepTimeout returns the default timeout of steps.
+// It returns 0 if the timeout is not set.
+func (c *Context) StepTimeout() time.Duration {
+	d, ok := c.ctx.Value(keyStepTimeout{}).(time.Duration)
+	if ok {
+		return d
+	}
+	return 0
+}
+
 // WithPlugins returns a copy of c with ps.
 func (c *Context) WithPlugins(ps map[string]*plugin.Plugin) *Context {
 	if ps == nil {
@@ -126,6 +147,15 @@ func (c *Context) WithVars(v interface{}) *Context {
 	)
 }
 
+// WithoutVars returns a copy of c which has no variables.
+func (c *Context) WithoutVars() *Context {
+	return newContext(
+		context.WithValue(c.ctx, keyVars{}, Vars(nil)),
+		c.reqCtx,
+		c.reporter,
+	)
+}
+
 // Vars returns the context variables.
 func (c *Context) Vars() Vars {
 	vs, ok := c.ctx.Value(keyVars{}).(Vars)
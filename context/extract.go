@@ -4,6 +4,7 @@ const (
 	nameContext  = "ctx"
 	namePlugins  = "plugins"
 	nameVars     = "vars"
+	nameSecrets  = "secrets"
 	nameRequest  = "request"
 	nameResponse = "response"
 	nameEnv      = "env"
@@ -25,6 +26,11 @@ func (c *Context) ExtractByKey(key string) (interface{}, bool) {
 		if v != nil {
 			return v, true
 		}
+	case nameSecrets:
+		v := c.Secrets()
+		if v != nil {
+			return v, true
+		}
 	case nameRequest:
 		v := c.Request()
 		if v != nil {
@@ -45,6 +45,13 @@ func TestContext_ExtractKey(t *testing.T) {
 			query:  "vars.foo",
 			expect: "bar",
 		},
+		"secrets": {
+			ctx: func(ctx *Context) *Context {
+				return ctx.WithSecrets(map[string]interface{}{"token": "secret"})
+			},
+			query:  "secrets.token",
+			expect: "secret",
+		},
 		"request": {
 			ctx: func(ctx *Context) *Context {
 				return ctx.WithRequest(vars)
@@ -0,0 +1,229 @@
+package context
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/zoncoen/yaml"
+)
+
+// MaskedValue is the string which replaces the secret values.
+const MaskedValue = "*****"
+
+// DefaultMaskedFields is the names of the fields such as HTTP headers including credentials which are always masked.
+var DefaultMaskedFields = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+type (
+	keySecrets struct{}
+	keyMasker  struct{}
+)
+
+// WithSecrets returns a copy of c with secrets.
+// The secrets are referred as "{{secrets.name}}" like vars, and the values are masked in the logs and reports.
+func (c *Context) WithSecrets(secrets map[string]interface{}) *Context {
+	if secrets == nil {
+		return c
+	}
+	merged := map[string]interface{}{}
+	for k, v := range c.Secrets() {
+		merged[k] = v
+	}
+	for k, v := range secrets {
+		merged[k] = v
+	}
+	ctx := context.WithValue(c.ctx, keySecrets{}, merged)
+	ctx = context.WithValue(ctx, keyMasker{}, c.Masker().withValues(secretValues(secrets)...))
+	return newContext(ctx, c.reqCtx, c.reporter)
+}
+
+// Secrets returns the secrets.
+func (c *Context) Secrets() map[string]interface{} {
+	s, ok := c.ctx.Value(keySecrets{}).(map[string]interface{})
+	if ok {
+		return s
+	}
+	return nil
+}
+
+// WithMaskedFields returns a copy of c which masks the values of the fields such as HTTP headers in addition to DefaultMaskedFields.
+// The names are case-insensitive.
+func (c *Context) WithMaskedFields(names ...string) *Context {
+	if len(names) == 0 {
+		return c
+	}
+	return newContext(
+		context.WithValue(c.ctx, keyMasker{}, c.Masker().withFields(names...)),
+		c.reqCtx,
+		c.reporter,
+	)
+}
+
+// Masker returns the masker to hide the secrets.
+func (c *Context) Masker() *Masker {
+	m, ok := c.ctx.Value(keyMasker{}).(*Masker)
+	if ok {
+		return m
+	}
+	return &Masker{}
+}
+
+// withDefaultMaskedFields returns a context.Context whose masker masks DefaultMaskedFields.
+func withDefaultMaskedFields(ctx context.Context) context.Context {
+	return context.WithValue(ctx, keyMasker{}, (&Masker{}).withFields(DefaultMaskedFields...))
+}
+
+// secretValues returns the scalar values in v as strings.
+func secretValues(v interface{}) []string {
+	var values []string
+	switch v := v.(type) {
+	case nil:
+	case map[string]interface{}:
+		for _, e := range v {
+			values = append(values, secretValues(e)...)
+		}
+	case map[interface{}]interface{}:
+		for _, e := range v {
+			values = append(values, secretValues(e)...)
+		}
+	case []interface{}:
+		for _, e := range v {
+			values = append(values, secretValues(e)...)
+		}
+	default:
+		if s := fmt.Sprint(v); s != "" {
+			values = append(values, s)
+		}
+	}
+	return values
+}
+
+// Masker hides the secret values and the values of the specific fields.
+// It is immutable and safe for concurrent use.
+type Masker struct {
+	values   []string
+	fields   map[string]struct{}
+	replacer *strings.Replacer
+}
+
+func (m *Masker) withValues(values ...string) *Masker {
+	newValues := append([]string{}, m.values...)
+	for _, v := range values {
+		if v != "" {
+			newValues = append(newValues, v)
+		}
+	}
+	// replace longer values first not to leave the parts of them
+	sort.SliceStable(newValues, func(i, j int) bool {
+		return len(newValues[i]) > len(newValues[j])
+	})
+	oldnew := make([]string, 0, len(newValues)*2)
+	for _, v := range newValues {
+		oldnew = append(oldnew, v, MaskedValue)
+	}
+	return &Masker{
+		values:   newValues,
+		fields:   m.fields,
+		replacer: strings.NewReplacer(oldnew...),
+	}
+}
+
+func (m *Masker) withFields(names ...string) *Masker {
+	fields := make(map[string]struct{}, len(m.fields)+len(names))
+	for name := range m.fields {
+		fields[name] = struct{}{}
+	}
+	for _, name := range names {
+		fields[strings.ToLower(name)] = struct{}{}
+	}
+	return &Masker{
+		values:   m.values,
+		fields:   fields,
+		replacer: m.replacer,
+	}
+}
+
+// isNop reports whether m masks nothing. A nil masker masks nothing.
+func (m *Masker) isNop() bool {
+	return m == nil || (len(m.values) == 0 && len(m.fields) == 0)
+}
+
+// MaskString replaces the secret values in s with MaskedValue.
+func (m *Masker) MaskString(s string) string {
+	if m == nil || m.replacer == nil {
+		return s
+	}
+	return m.replacer.Replace(s)
+}
+
+// MaskValue returns a copy of v whose secret values and the values of the masked fields are replaced with MaskedValue.
+// v is converted to a generic value which has the same YAML representation to traverse any types such as protocol requests.
+// It returns v as it is if v has nothing to mask or can not be converted.
+func (m *Masker) MaskValue(v interface{}) interface{} {
+	if v == nil || m.isNop() {
+		return v
+	}
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var generic yaml.KeyOrderPreservedInterface
+	if err := yaml.Unmarshal(b, &generic); err != nil {
+		return v
+	}
+	if masked, ok := m.mask(generic, false); ok {
+		return masked
+	}
+	return v
+}
+
+// mask returns the masked copy of v and reports whether some values are masked.
+func (m *Masker) mask(v interface{}, force bool) (interface{}, bool) {
+	switch v := v.(type) {
+	case yaml.MapSlice:
+		s := make(yaml.MapSlice, len(v))
+		var masked bool
+		for i, item := range v {
+			_, isField := m.fields[strings.ToLower(fmt.Sprint(item.Key))]
+			value, ok := m.mask(item.Value, force || isField)
+			s[i] = yaml.MapItem{Key: item.Key, Value: value}
+			masked = masked || ok
+		}
+		return s, masked
+	case []interface{}:
+		return m.maskSlice(v, force)
+	case []yaml.KeyOrderPreservedInterface:
+		s := make([]interface{}, len(v))
+		for i, e := range v {
+			s[i] = e
+		}
+		return m.maskSlice(s, force)
+	case nil:
+		return nil, false
+	case string:
+		if force {
+			return MaskedValue, true
+		}
+		if s := m.MaskString(v); s != v {
+			return s, true
+		}
+		return v, false
+	default:
+		if force || m.MaskString(fmt.Sprint(v)) != fmt.Sprint(v) {
+			return MaskedValue, true
+		}
+		return v, false
+	}
+}
+
+func (m *Masker) maskSlice(v []interface{}, force bool) (interface{}, bool) {
+	s := make([]interface{}, len(v))
+	var masked bool
+	for i, e := range v {
+		value, ok := m.mask(e, force)
+		s[i] = value
+		masked = masked || ok
+	}
+	return s, masked
+}
@@ -0,0 +1,161 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zoncoen/yaml"
+)
+
+func TestContext_WithSecrets(t *testing.T) {
+	ctx := New(nil).
+		WithSecrets(map[string]interface{}{"user": "alice", "password": "secret"}).
+		WithSecrets(map[string]interface{}{"password": "p@ssw0rd", "keys": []interface{}{"key1", 2}})
+	expect := map[string]interface{}{
+		"user":     "alice",
+		"password": "p@ssw0rd",
+		"keys":     []interface{}{"key1", 2},
+	}
+	if diff := cmp.Diff(expect, ctx.Secrets()); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	// the overridden secret is still masked
+	if got, expect := ctx.Masker().MaskString("alice secret p@ssw0rd key1 2"), "***** ***** ***** ***** *****"; got != expect {
+		t.Errorf("expected %q but got %q", expect, got)
+	}
+	if got := New(nil).WithSecrets(nil); got.Secrets() != nil {
+		t.Errorf("expected no secrets but got %v", got.Secrets())
+	}
+}
+
+func TestContext_Masker(t *testing.T) {
+	in := map[string]string{"Authorization": "Bearer xxx"}
+	tests := map[string]struct {
+		ctx    *Context
+		expect interface{}
+	}{
+		"default": {
+			ctx:    New(nil),
+			expect: yaml.MapSlice{{Key: "Authorization", Value: MaskedValue}},
+		},
+		"secrets": {
+			ctx:    New(nil).WithSecrets(map[string]interface{}{}),
+			expect: yaml.MapSlice{{Key: "Authorization", Value: MaskedValue}},
+		},
+		"masked fields": {
+			ctx:    New(nil).WithMaskedFields("password"),
+			expect: yaml.MapSlice{{Key: "Authorization", Value: MaskedValue}},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expect, test.ctx.Masker().MaskValue(in)); diff != "" {
+				t.Errorf("differs: (-want +got)\n%s", diff)
+			}
+		})
+	}
+	var m *Masker
+	if got := m.MaskString("secret"); got != "secret" {
+		t.Errorf("nil masker masks %q", got)
+	}
+}
+
+func TestMasker_MaskString(t *testing.T) {
+	m := New(nil).WithSecrets(map[string]interface{}{
+		"short": "abc",
+		"long":  "abcdef",
+		"empty": "",
+	}).Masker()
+	tests := map[string]struct {
+		in     string
+		expect string
+	}{
+		"no secrets": {
+			in:     "hello",
+			expect: "hello",
+		},
+		"longer value first": {
+			in:     "token=abcdefg",
+			expect: "token=*****g",
+		},
+		"multiple": {
+			in:     "abc/abc",
+			expect: "*****/*****",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if got := m.MaskString(test.in); got != test.expect {
+				t.Errorf("expected %q but got %q", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestMasker_MaskValue(t *testing.T) {
+	type request struct {
+		Header map[string][]string `yaml:"header"`
+		Body   interface{}         `yaml:"body"`
+	}
+	ctx := New(nil).
+		WithSecrets(map[string]interface{}{"token": "t0ken"}).
+		WithMaskedFields("Password")
+	tests := map[string]struct {
+		in     interface{}
+		expect interface{}
+	}{
+		"nil": {},
+		"nothing to mask": {
+			in:     map[string]string{"message": "hello"},
+			expect: map[string]string{"message": "hello"},
+		},
+		"secret values": {
+			in: map[string]interface{}{
+				"token":   "t0ken",
+				"message": "token is t0ken",
+				"list":    []string{"t0ken", "ok"},
+			},
+			expect: yaml.MapSlice{
+				{Key: "list", Value: []interface{}{MaskedValue, "ok"}},
+				{Key: "message", Value: "token is " + MaskedValue},
+				{Key: "token", Value: MaskedValue},
+			},
+		},
+		"fields": {
+			in: &request{
+				Header: map[string][]string{
+					"Authorization": {"Bearer xxx"},
+					"Content-Type":  {"application/json"},
+				},
+				Body: map[string]interface{}{
+					"user": map[string]interface{}{
+						"name":     "alice",
+						"password": 1234,
+					},
+				},
+			},
+			expect: yaml.MapSlice{
+				{Key: "header", Value: yaml.MapSlice{
+					{Key: "Authorization", Value: []interface{}{MaskedValue}},
+					{Key: "Content-Type", Value: []interface{}{"application/json"}},
+				}},
+				{Key: "body", Value: yaml.MapSlice{
+					{Key: "user", Value: yaml.MapSlice{
+						{Key: "name", Value: "alice"},
+						{Key: "password", Value: MaskedValue},
+					}},
+				}},
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expect, ctx.Masker().MaskValue(test.in)); diff != "" {
+				t.Errorf("differs: (-want +got)\n%s", diff)
+			}
+		})
+	}
+}
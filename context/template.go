@@ -11,6 +11,7 @@ import (
 var yamlMapItemType = reflect.TypeOf(yaml.MapItem{})
 
 // ExecuteTemplate executes template strings in context.
+// It returns a copy of i and never modifies i to enable to execute the same templates repeatedly.
 func (ctx *Context) ExecuteTemplate(i interface{}) (interface{}, error) {
 	v, err := ctx.executeTemplate(reflect.ValueOf(i))
 	if err != nil {
@@ -34,6 +35,10 @@ func (ctx *Context) executeTemplate(v reflect.Value) (reflect.Value, error) {
 	}
 	switch v.Kind() {
 	case reflect.Map:
+		if v.IsNil() {
+			return v, nil
+		}
+		m := reflect.MakeMapWithSize(v.Type(), v.Len())
 		for _, k := range v.MapKeys() {
 			e := v.MapIndex(k)
 			if !isNil(e) {
@@ -41,10 +46,16 @@ func (ctx *Context) executeTemplate(v reflect.Value) (reflect.Value, error) {
 				if err != nil {
 					return reflect.Value{}, err
 				}
-				v.SetMapIndex(k, x)
+				e = x
 			}
+			m.SetMapIndex(k, e)
 		}
+		return m, nil
 	case reflect.Slice:
+		if v.IsNil() {
+			return v, nil
+		}
+		s := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
 		for i := 0; i < v.Len(); i++ {
 			e := v.Index(i)
 			if !isNil(e) {
@@ -52,13 +63,17 @@ func (ctx *Context) executeTemplate(v reflect.Value) (reflect.Value, error) {
 				if err != nil {
 					return reflect.Value{}, err
 				}
-				e.Set(x)
+				e = x
 			}
+			s.Index(i).Set(e)
 		}
+		return s, nil
 	case reflect.Struct:
+		s := reflect.New(v.Type()).Elem()
+		s.Set(v)
 		switch v.Type() {
 		case yamlMapItemType:
-			value := v.FieldByName("Value")
+			value := s.FieldByName("Value")
 			if !isNil(value) {
 				x, err := ctx.executeTemplate(value)
 				if err != nil {
@@ -67,14 +82,21 @@ func (ctx *Context) executeTemplate(v reflect.Value) (reflect.Value, error) {
 				value.Set(x)
 			}
 		default:
-			for i := 0; i < v.NumField(); i++ {
-				field := v.Field(i)
-				_, err := ctx.executeTemplate(field)
+			for i := 0; i < s.NumField(); i++ {
+				field := s.Field(i)
+				if !field.CanSet() {
+					continue // unexported
+				}
+				x, err := ctx.executeTemplate(field)
 				if err != nil {
 					return reflect.Value{}, err
 				}
+				if err := setField(field, x); err != nil {
+					return reflect.Value{}, errors.Wrapf(err, `failed to set the field "%s" of %s`, s.Type().Field(i).Name, s.Type())
+				}
 			}
 		}
+		return s, nil
 	case reflect.String:
 		tmpl, err := template.New(v.String())
 		if err != nil {
@@ -89,6 +111,31 @@ func (ctx *Context) executeTemplate(v reflect.Value) (reflect.Value, error) {
 	return v, nil
 }
 
+// setField sets the result of the template x to field.
+// A pointer to x is set if field is a pointer, and x is converted if field is a named type of the same kind.
+func setField(field, x reflect.Value) error {
+	if !x.IsValid() {
+		if !isNil(reflect.Zero(field.Type())) {
+			return errors.Errorf("can not assign nil to %s", field.Type())
+		}
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	switch {
+	case x.Type().AssignableTo(field.Type()):
+		field.Set(x)
+	case field.Kind() == reflect.Ptr && x.Type().AssignableTo(field.Type().Elem()):
+		p := reflect.New(field.Type().Elem())
+		p.Elem().Set(x)
+		field.Set(p)
+	case x.Kind() == field.Kind() && x.Type().ConvertibleTo(field.Type()):
+		field.Set(x.Convert(field.Type()))
+	default:
+		return errors.Errorf("can not assign %s to %s", x.Type(), field.Type())
+	}
+	return nil
+}
+
 func isNil(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.Chan, reflect.Func, reflect.Map, reflect.Ptr, reflect.UnsafePointer, reflect.Interface, reflect.Slice:
@@ -113,6 +113,37 @@ func TestContext_ExecuteTemplate(t *testing.T) {
 				},
 			},
 		},
+		"struct": {
+			in: templateStruct{
+				Name:  `{{"Bob"}}`,
+				Age:   `{{20}}`,
+				ID:    templateID(`{{"id"}}`),
+				Ptr:   &templateStruct{Name: `{{"Alice"}}`, ID: "alice"},
+				Value: `{{1}}`,
+			},
+			expected: templateStruct{
+				Name:  "Bob",
+				Age:   20,
+				ID:    templateID("id"),
+				Ptr:   &templateStruct{Name: "Alice", ID: "alice"},
+				Value: 1,
+			},
+		},
+		"struct (field is nil)": {
+			in: templateStruct{
+				Name:  "Bob",
+				ID:    "bob",
+				Ptr:   nil,
+				Value: `{{vars.nil}}`,
+			},
+			expected: templateStruct{
+				Name: "Bob",
+				ID:   "bob",
+			},
+			vars: map[string]interface{}{
+				"nil": nil,
+			},
+		},
 	}
 	for name, test := range tests {
 		test := test
@@ -131,3 +162,83 @@ func TestContext_ExecuteTemplate(t *testing.T) {
 		})
 	}
 }
+
+type templateID string
+
+type templateStruct struct {
+	Name  string
+	Age   interface{}
+	ID    templateID
+	Ptr   *templateStruct
+	Value interface{}
+}
+
+func TestContext_ExecuteTemplate_InvalidField(t *testing.T) {
+	tests := map[string]struct {
+		in     interface{}
+		expect string
+	}{
+		"type mismatch": {
+			in:     templateStruct{Name: "{{1}}", ID: "id"},
+			expect: `failed to execute template: failed to set the field "Name" of context.templateStruct: can not assign int to string`,
+		},
+		"nil": {
+			in:     templateStruct{Name: "{{vars.nil}}", ID: "id"},
+			expect: `failed to execute template: failed to set the field "Name" of context.templateStruct: can not assign nil to string`,
+		},
+		"nested": {
+			in:     templateStruct{Name: "name", ID: "id", Ptr: &templateStruct{Name: "name", ID: "{{1}}"}},
+			expect: `failed to execute template: failed to set the field "ID" of context.templateStruct: can not assign int to context.templateID`,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			ctx := New(reporter.FromT(t)).WithVars(map[string]interface{}{"nil": nil})
+			_, err := ctx.ExecuteTemplate(test.in)
+			if err == nil {
+				t.Fatal("expected error but no error")
+			}
+			if got := err.Error(); got != test.expect {
+				t.Errorf("expect %q but got %q", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestContext_ExecuteTemplate_NotModifyInput(t *testing.T) {
+	in := map[string]interface{}{
+		"map":   map[interface{}]interface{}{"name": "{{vars.name}}"},
+		"slice": []interface{}{"{{vars.name}}"},
+		"yaml": yaml.MapSlice{
+			yaml.MapItem{Key: "name", Value: "{{vars.name}}"},
+		},
+	}
+	expect := map[string]interface{}{
+		"map":   map[interface{}]interface{}{"name": "{{vars.name}}"},
+		"slice": []interface{}{"{{vars.name}}"},
+		"yaml": yaml.MapSlice{
+			yaml.MapItem{Key: "name", Value: "{{vars.name}}"},
+		},
+	}
+	for _, name := range []string{"alice", "bob"} {
+		ctx := New(reporter.FromT(t)).WithVars(map[string]string{"name": name})
+		got, err := ctx.ExecuteTemplate(in)
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		executed := map[string]interface{}{
+			"map":   map[interface{}]interface{}{"name": name},
+			"slice": []interface{}{name},
+			"yaml": yaml.MapSlice{
+				yaml.MapItem{Key: "name", Value: name},
+			},
+		}
+		if diff := cmp.Diff(executed, got); diff != "" {
+			t.Errorf("differs: (-want +got)\n%s", diff)
+		}
+	}
+	if diff := cmp.Diff(expect, in); diff != "" {
+		t.Errorf("input is modified: (-want +got)\n%s", diff)
+	}
+}
@@ -8,13 +8,13 @@ import (
 // Vars represents context variables.
 type Vars []interface{}
 
-// Append appends v to context variables.
+// Append returns a new Vars which has v in addition to vars.
+// It never writes to the backing array of vars because contexts derived from the same context may append concurrently.
 func (vars Vars) Append(v interface{}) Vars {
 	if v == nil {
 		return vars
 	}
-	vars = append(vars, v)
-	return vars
+	return append(vars[:len(vars):len(vars)], v)
 }
 
 // ExtractByKey implements query.KeyExtractor interface.
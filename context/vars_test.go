@@ -0,0 +1,25 @@
+package context
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVars_Append(t *testing.T) {
+	parent := make(Vars, 0, 4).Append("a").Append("b")
+	x := parent.Append("x")
+	y := parent.Append("y")
+	if diff := cmp.Diff(Vars{"a", "b", "x"}, x); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff(Vars{"a", "b", "y"}, y); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff(Vars{"a", "b"}, parent); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if got := parent.Append(nil); len(got) != 2 {
+		t.Errorf("nil must not be appended: %v", got)
+	}
+}
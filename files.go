@@ -0,0 +1,212 @@
+package scenarigo
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/internal/glob"
+)
+
+// IgnoreFileName is the name of the file which specifies the files to ignore
+// when finding scenario files in the directory, like ".gitignore".
+const IgnoreFileName = ".scenarigoignore"
+
+var defaultScenarioExtensions = []string{".yaml", ".yml"}
+
+// findScenarioFiles returns the scenario files specified by paths without duplicates.
+func (r *Runner) findScenarioFiles(paths ...string) ([]string, error) {
+	files := []string{}
+	found := map[string]bool{}
+	for _, path := range paths {
+		var fs []string
+		var err error
+		if glob.HasMeta(path) {
+			fs, err = r.globFiles(path)
+		} else {
+			fs, err = r.getAllFiles(path, nil, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fs {
+			if !found[f] {
+				found[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+// globFiles returns the scenario files which match pattern or are in the directories which match pattern.
+func (r *Runner) globFiles(pattern string) ([]string, error) {
+	pattern = filepath.Clean(pattern)
+	if err := glob.Validate(filepath.ToSlash(pattern)); err != nil {
+		return nil, errors.Wrapf(err, `invalid pattern "%s"`, pattern)
+	}
+	base := glob.Base(pattern)
+	if _, err := os.Stat(base); os.IsNotExist(err) {
+		return nil, errors.Errorf(`no files match the pattern "%s"`, pattern)
+	}
+	all, err := r.getAllFiles(base, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, f := range all {
+		for p := f; p != base && p != "."; p = filepath.Dir(p) {
+			ok, err := glob.Match(filepath.ToSlash(pattern), filepath.ToSlash(p))
+			if err != nil {
+				return nil, errors.Wrapf(err, `invalid pattern "%s"`, pattern)
+			}
+			if ok {
+				files = append(files, f)
+				break
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf(`no files match the pattern "%s"`, pattern)
+	}
+	return files, nil
+}
+
+// getAllFiles returns path if path is a file, otherwise finds scenario files in path recursively.
+// If filter is true, files which do not have the scenario file extensions are excluded even if path is a file.
+func (r *Runner) getAllFiles(path string, rules []*ignoreRule, filter bool) ([]string, error) {
+	p, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsDir() {
+		if filter && !r.hasScenarioExtension(path) {
+			return nil, nil
+		}
+		return []string{path}, nil
+	}
+
+	rs, err := loadIgnoreRules(path)
+	if err != nil {
+		return nil, err
+	}
+	rules = append(rules[:len(rules):len(rules)], rs...)
+
+	fis, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, fi := range fis {
+		child := filepath.Join(path, fi.Name())
+		if fi.Name() == IgnoreFileName {
+			continue
+		}
+		ignored, err := isIgnored(rules, child, fi.IsDir())
+		if err != nil {
+			return nil, err
+		}
+		if ignored {
+			continue
+		}
+		fs, err := r.getAllFiles(child, rules, true)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, fs...)
+	}
+	return files, nil
+}
+
+func (r *Runner) hasScenarioExtension(path string) bool {
+	ext := filepath.Ext(path)
+	for _, e := range r.extensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoreRule represents a pattern of the ignore file.
+type ignoreRule struct {
+	base    string // directory of the ignore file
+	pattern string
+	negate  bool // re-include the matched files
+	dirOnly bool // match only directories
+}
+
+// loadIgnoreRules loads the ignore file in dir.
+// Blank lines and lines starting with "#" are ignored.
+// A pattern starting with "!" re-includes the files excluded by a previous pattern.
+// A pattern ending with "/" matches only directories.
+// A pattern containing "/" is relative to dir, otherwise it matches the name at any level below dir.
+func loadIgnoreRules(dir string) ([]*ignoreRule, error) {
+	path := filepath.Join(dir, IgnoreFileName)
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var rules []*ignoreRule
+	s := bufio.NewScanner(f)
+	for i := 1; s.Scan(); i++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rule := &ignoreRule{base: dir}
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			line = strings.TrimPrefix(line, "/")
+		} else {
+			line = "**/" + line
+		}
+		if err := glob.Validate(line); err != nil {
+			return nil, errors.Wrapf(err, "%s:%d: invalid pattern", path, i)
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, `failed to read "%s"`, path)
+	}
+	return rules, nil
+}
+
+// isIgnored reports whether path is ignored by rules.
+// The last matched rule decides it.
+func isIgnored(rules []*ignoreRule, path string, isDir bool) (bool, error) {
+	var ignored bool
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		rel, err := filepath.Rel(rule.base, path)
+		if err != nil {
+			continue
+		}
+		ok, err := glob.Match(rule.pattern, filepath.ToSlash(rel))
+		if err != nil {
+			return false, errors.Wrapf(err, `invalid pattern "%s"`, rule.pattern)
+		}
+		if ok {
+			ignored = !rule.negate
+		}
+	}
+	return ignored, nil
+}
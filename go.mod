@@ -27,4 +27,5 @@ require (
 	golang.org/x/tools v0.0.0-20190624150748-8ea4f8e3e5bf // indirect
 	google.golang.org/grpc v1.21.1
 	gopkg.in/yaml.v2 v2.2.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1
 )
@@ -0,0 +1,123 @@
+package scenarigo
+
+import (
+	"path/filepath"
+	"plugin"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+// WithSetup returns a option which sets the hooks to run once before all scenarios.
+// The variables of the setup scenarios and the contexts returned by the setup functions
+// are visible to all scenarios and teardown hooks.
+// If a setup hook failed, the remaining hooks and all scenarios are not run.
+func WithSetup(hooks ...*schema.Hook) func(*Runner) error {
+	return func(r *Runner) error {
+		for i, h := range hooks {
+			if err := h.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid setup[%d]", i)
+			}
+		}
+		r.setup = hooks
+		return nil
+	}
+}
+
+// WithTeardown returns a option which sets the hooks to run once after all scenarios.
+// All teardown hooks are run even if the setup hooks, the scenarios or the other teardown hooks failed.
+func WithTeardown(hooks ...*schema.Hook) func(*Runner) error {
+	return func(r *Runner) error {
+		for i, h := range hooks {
+			if err := h.Validate(); err != nil {
+				return errors.Wrapf(err, "invalid teardown[%d]", i)
+			}
+		}
+		r.teardown = hooks
+		return nil
+	}
+}
+
+// hookFunc represents a function of a plugin which is used as hook.
+type hookFunc = func(*context.Context) *context.Context
+
+// runHooks runs hooks as a subtest called name and returns the context which has the variables of the hooks.
+// The remaining hooks are not run if a hook failed unless always is true.
+func runHooks(ctx *context.Context, name string, hooks []*schema.Hook, always bool) (*context.Context, []*ScenarioResult, bool) {
+	results := []*ScenarioResult{}
+	hookCtx := ctx
+	run := func(ctx *context.Context, title string, f func(*context.Context, *ScenarioResult) *context.Context) bool {
+		result := &ScenarioResult{
+			Title: title,
+			Steps: []*StepResult{},
+		}
+		results = append(results, result)
+		return hookCtx.WithReporter(ctx.Reporter()).Run(title, func(ctx *context.Context) {
+			start := time.Now()
+			ctx = ctx.WithReporter(newLogRecorder(ctx, &result.Logs))
+			defer func() {
+				result.Status = statusOf(ctx.Reporter())
+				result.Duration = time.Since(start)
+			}()
+			hookCtx = f(ctx, result)
+		})
+	}
+	ok := ctx.Run(name, func(ctx *context.Context) {
+		for _, h := range hooks {
+			h := h
+			if h.Plugin != "" {
+				ok := run(ctx, h.Name(), func(ctx *context.Context, _ *ScenarioResult) *context.Context {
+					return runPluginHook(ctx, h)
+				})
+				if !ok && !always {
+					ctx.Reporter().FailNow()
+				}
+				continue
+			}
+			scns, err := schema.LoadScenarios(h.Scenario)
+			if err != nil {
+				ctx.Reporter().Errorf("failed to load scenarios: %s", err)
+				if !always {
+					ctx.Reporter().FailNow()
+				}
+				continue
+			}
+			for _, scn := range scns {
+				scn := scn
+				ok := run(ctx, scn.Title, func(ctx *context.Context, result *ScenarioResult) *context.Context {
+					return runScenario(ctx, scn, result)
+				})
+				if !ok && !always {
+					ctx.Reporter().FailNow()
+				}
+			}
+		}
+	})
+	return hookCtx, results, ok
+}
+
+// runPluginHook calls the function of the plugin.
+func runPluginHook(ctx *context.Context, h *schema.Hook) *context.Context {
+	path := h.Plugin
+	if root := ctx.PluginDir(); root != "" {
+		path = filepath.Join(root, path)
+	}
+	p, err := plugin.Open(path)
+	if err != nil {
+		ctx.Reporter().Fatalf("failed to open plugin: %s", err)
+	}
+	sym, err := p.Lookup(h.Func)
+	if err != nil {
+		ctx.Reporter().Fatalf("failed to find function: %s", err)
+	}
+	f, ok := sym.(hookFunc)
+	if !ok {
+		ctx.Reporter().Fatalf("%s must be func(*plugin.Context) *plugin.Context but got %T", h.Func, sym)
+	}
+	if newCtx := f(ctx); newCtx != nil {
+		ctx = newCtx
+	}
+	return ctx
+}
@@ -0,0 +1,76 @@
+// Package glob provides glob pattern matching which supports "**" to match any number of directories.
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// HasMeta reports whether pattern contains any of the magic characters.
+func HasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+// Validate returns path.ErrBadPattern if pattern is malformed.
+// Every segment is checked because Match stops at the first segment which does not match.
+func Validate(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if _, err := path.Match(seg, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match reports whether name matches the slash-separated pattern.
+// In addition to the syntax of path.Match, "**" matches zero or more directories.
+func Match(pattern, name string) (bool, error) {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(patterns, names []string) (bool, error) {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for len(patterns) > 0 && patterns[0] == "**" {
+				patterns = patterns[1:]
+			}
+			if len(patterns) == 0 {
+				return true, nil
+			}
+			for i := range names {
+				ok, err := matchSegments(patterns, names[i:])
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(names) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(patterns[0], names[0])
+		if err != nil || !ok {
+			return false, err
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0, nil
+}
+
+// Base returns the longest leading directory of pattern which does not contain any magic characters.
+func Base(pattern string) string {
+	segments := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+	var i int
+	for i < len(segments)-1 && !HasMeta(segments[i]) {
+		i++
+	}
+	base := strings.Join(segments[:i], "/")
+	if base == "" {
+		if strings.HasPrefix(filepath.ToSlash(pattern), "/") {
+			return string(filepath.Separator)
+		}
+		return "."
+	}
+	return filepath.FromSlash(base)
+}
@@ -0,0 +1,92 @@
+package glob
+
+import (
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		name    string
+		ok      bool
+	}{
+		{pattern: "*.yaml", name: "a.yaml", ok: true},
+		{pattern: "*.yaml", name: "dir/a.yaml", ok: false},
+		{pattern: "dir/*.yaml", name: "dir/a.yaml", ok: true},
+		{pattern: "**/*.yaml", name: "a.yaml", ok: true},
+		{pattern: "**/*.yaml", name: "dir/sub/a.yaml", ok: true},
+		{pattern: "**/*.yaml", name: "dir/sub/a.json", ok: false},
+		{pattern: "dir/**", name: "dir/sub/a.yaml", ok: true},
+		{pattern: "dir/**", name: "other/a.yaml", ok: false},
+		{pattern: "dir/**/a.yaml", name: "dir/a.yaml", ok: true},
+		{pattern: "dir/**/a.yaml", name: "dir/x/y/a.yaml", ok: true},
+		{pattern: "dir/**/a.yaml", name: "dir/x/y/b.yaml", ok: false},
+		{pattern: "a?.y[am]ml", name: "ab.yaml", ok: true},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.pattern+" "+test.name, func(t *testing.T) {
+			ok, err := Match(test.pattern, test.name)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if ok != test.ok {
+				t.Errorf("expected %t but got %t", test.ok, ok)
+			}
+		})
+	}
+	t.Run("bad pattern", func(t *testing.T) {
+		if _, err := Match("[", "a"); err == nil {
+			t.Fatal("expected error but no error")
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		ok      bool
+	}{
+		"valid":                   {pattern: "dir/**/a?.y[am]ml", ok: true},
+		"bad first segment":       {pattern: "[/a.yaml"},
+		"bad last segment":        {pattern: "dir/sub/[.yaml"},
+		"bad segment after **":    {pattern: "**/a\\"},
+		"bad segment after match": {pattern: "*/b[-]"},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := Validate(test.pattern)
+			if test.ok {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err != path.ErrBadPattern {
+				t.Fatalf("expected ErrBadPattern but got %v", err)
+			}
+		})
+	}
+}
+
+func TestBase(t *testing.T) {
+	tests := map[string]string{
+		"*.yaml":                  ".",
+		"dir/*.yaml":              "dir",
+		"./dir/sub/**/*.yaml":     "dir/sub",
+		"/root/dir/*/a.yaml":      "/root/dir",
+		"dir/a.yaml":              "dir",
+		"dir/su?/nested/**/*.yml": "dir",
+	}
+	for pattern, expect := range tests {
+		pattern, expect := pattern, expect
+		t.Run(pattern, func(t *testing.T) {
+			if got := Base(pattern); got != filepath.FromSlash(expect) {
+				t.Errorf("expected %q but got %q", expect, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Package tagexpr implements boolean expressions to select scenarios by tags.
+//
+// An expression consists of tag names, "!" (not), "&&" (and), "||" (or) and parentheses.
+// For example, "smoke && !slow" matches scenarios which have the "smoke" tag but do not have the "slow" tag.
+package tagexpr
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Expr represents a tag expression.
+type Expr interface {
+	// Match reports whether the tags satisfy the expression.
+	Match(tags []string) bool
+	String() string
+}
+
+type tagExpr string
+
+func (e tagExpr) Match(tags []string) bool {
+	for _, tag := range tags {
+		if tag == string(e) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e tagExpr) String() string { return string(e) }
+
+type notExpr struct{ x Expr }
+
+func (e *notExpr) Match(tags []string) bool { return !e.x.Match(tags) }
+
+func (e *notExpr) String() string { return fmt.Sprintf("!%s", e.x) }
+
+type andExpr struct{ x, y Expr }
+
+func (e *andExpr) Match(tags []string) bool { return e.x.Match(tags) && e.y.Match(tags) }
+
+func (e *andExpr) String() string { return fmt.Sprintf("(%s && %s)", e.x, e.y) }
+
+type orExpr struct{ x, y Expr }
+
+func (e *orExpr) Match(tags []string) bool { return e.x.Match(tags) || e.y.Match(tags) }
+
+func (e *orExpr) String() string { return fmt.Sprintf("(%s || %s)", e.x, e.y) }
+
+// Parse parses s as a tag expression.
+func Parse(s string) (Expr, error) {
+	p := &parser{src: []rune(s)}
+	p.next()
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != tokEOF {
+		return nil, p.errorf("unexpected %s", p.tok.describe(p.lit))
+	}
+	return e, nil
+}
+
+type token int
+
+const (
+	tokEOF token = iota
+	tokTag
+	tokNot
+	tokAnd
+	tokOr
+	tokLParen
+	tokRParen
+	tokIllegal
+)
+
+func (t token) describe(lit string) string {
+	switch t {
+	case tokEOF:
+		return "end of expression"
+	case tokTag:
+		return fmt.Sprintf(`tag "%s"`, lit)
+	}
+	return fmt.Sprintf(`"%s"`, lit)
+}
+
+type parser struct {
+	src    []rune
+	offset int
+
+	pos int // position of the current token
+	tok token
+	lit string
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return errors.Errorf("col %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) next() {
+	for p.offset < len(p.src) && unicode.IsSpace(p.src[p.offset]) {
+		p.offset++
+	}
+	p.pos = p.offset
+	if p.offset >= len(p.src) {
+		p.tok, p.lit = tokEOF, ""
+		return
+	}
+	ch := p.src[p.offset]
+	p.offset++
+	switch ch {
+	case '!':
+		p.tok, p.lit = tokNot, "!"
+	case '(':
+		p.tok, p.lit = tokLParen, "("
+	case ')':
+		p.tok, p.lit = tokRParen, ")"
+	case '&', '|':
+		if p.offset < len(p.src) && p.src[p.offset] == ch {
+			p.offset++
+			if ch == '&' {
+				p.tok, p.lit = tokAnd, "&&"
+			} else {
+				p.tok, p.lit = tokOr, "||"
+			}
+			return
+		}
+		p.tok, p.lit = tokIllegal, string(ch)
+	default:
+		if !isTagChar(ch) {
+			p.tok, p.lit = tokIllegal, string(ch)
+			return
+		}
+		var b strings.Builder
+		b.WriteRune(ch)
+		for p.offset < len(p.src) && isTagChar(p.src[p.offset]) {
+			b.WriteRune(p.src[p.offset])
+			p.offset++
+		}
+		p.tok, p.lit = tokTag, b.String()
+	}
+}
+
+func isTagChar(ch rune) bool {
+	switch ch {
+	case '!', '&', '|', '(', ')':
+		return false
+	}
+	return !unicode.IsSpace(ch) && unicode.IsPrint(ch)
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == tokOr {
+		p.next()
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &orExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == tokAnd {
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &andExpr{x: x, y: y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	switch p.tok {
+	case tokNot:
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{x: x}, nil
+	case tokLParen:
+		p.next()
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.tok != tokRParen {
+			return nil, p.errorf(`expected ")" but found %s`, p.tok.describe(p.lit))
+		}
+		p.next()
+		return x, nil
+	case tokTag:
+		x := tagExpr(p.lit)
+		p.next()
+		return x, nil
+	}
+	return nil, p.errorf("expected tag but found %s", p.tok.describe(p.lit))
+}
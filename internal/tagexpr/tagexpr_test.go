@@ -0,0 +1,120 @@
+package tagexpr
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tests := map[string]struct {
+			expr  string
+			str   string
+			match [][]string
+			not   [][]string
+		}{
+			"tag": {
+				expr:  "smoke",
+				str:   "smoke",
+				match: [][]string{{"smoke"}, {"slow", "smoke"}},
+				not:   [][]string{nil, {"slow"}},
+			},
+			"not": {
+				expr:  "!slow",
+				str:   "!slow",
+				match: [][]string{nil, {"smoke"}},
+				not:   [][]string{{"slow"}},
+			},
+			"and": {
+				expr:  "smoke && !slow",
+				str:   "(smoke && !slow)",
+				match: [][]string{{"smoke"}, {"smoke", "grpc"}},
+				not:   [][]string{{"smoke", "slow"}, {"slow"}, nil},
+			},
+			"or": {
+				expr:  "http||grpc",
+				str:   "(http || grpc)",
+				match: [][]string{{"http"}, {"grpc"}},
+				not:   [][]string{nil, {"smoke"}},
+			},
+			"precedence": {
+				expr:  "a || b && c",
+				str:   "(a || (b && c))",
+				match: [][]string{{"a"}, {"b", "c"}},
+				not:   [][]string{{"b"}, {"c"}},
+			},
+			"parentheses": {
+				expr:  "!(a || b) && c",
+				str:   "(!(a || b) && c)",
+				match: [][]string{{"c"}},
+				not:   [][]string{{"a", "c"}, {"b", "c"}, nil},
+			},
+			"tag with symbols": {
+				expr:  "team:payment && api/v1",
+				str:   "(team:payment && api/v1)",
+				match: [][]string{{"team:payment", "api/v1"}},
+				not:   [][]string{{"team:payment"}},
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				e, err := Parse(test.expr)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if got := e.String(); got != test.str {
+					t.Errorf("expected %q but got %q", test.str, got)
+				}
+				for _, tags := range test.match {
+					if !e.Match(tags) {
+						t.Errorf("%q should match %q", test.expr, tags)
+					}
+				}
+				for _, tags := range test.not {
+					if e.Match(tags) {
+						t.Errorf("%q should not match %q", test.expr, tags)
+					}
+				}
+			})
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			expr   string
+			expect string
+		}{
+			"empty": {
+				expr:   "",
+				expect: "col 1: expected tag but found end of expression",
+			},
+			"single ampersand": {
+				expr:   "a & b",
+				expect: `col 3: unexpected "&"`,
+			},
+			"missing operand": {
+				expr:   "a &&",
+				expect: "col 5: expected tag but found end of expression",
+			},
+			"missing operator": {
+				expr:   "a b",
+				expect: `col 3: unexpected tag "b"`,
+			},
+			"unclosed parenthesis": {
+				expr:   "(a || b",
+				expect: `col 8: expected ")" but found end of expression`,
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				_, err := Parse(test.expr)
+				if err == nil {
+					t.Fatal("expected error but no error")
+				}
+				if got := err.Error(); got != test.expect {
+					t.Errorf("expected %q but got %q", test.expect, got)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,186 @@
+// Package jsonschema provides JSON Schema (draft-07) to describe YAML files for editors.
+package jsonschema
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Draft07 is the URI of the JSON Schema draft-07 meta-schema.
+const Draft07 = "http://json-schema.org/draft-07/schema#"
+
+// durationPattern is the pattern of the strings which are parsed by time.ParseDuration.
+const durationPattern = `^[-+]?(([0-9]+(\.[0-9]*)?|\.[0-9]+)(ns|us|µs|ms|s|m|h))+$`
+
+// Schema represents a JSON Schema.
+type Schema struct {
+	Schema      string             `json:"$schema,omitempty"`
+	ID          string             `json:"$id,omitempty"`
+	Ref         string             `json:"$ref,omitempty"`
+	Title       string             `json:"title,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Type        interface{}        `json:"type,omitempty"` // string or []string
+	Enum        []interface{}      `json:"enum,omitempty"`
+	Const       interface{}        `json:"const,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Properties  map[string]*Schema `json:"properties,omitempty"`
+	Required    []string           `json:"required,omitempty"`
+	// AdditionalProperties is a *Schema or bool.
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	If                   *Schema            `json:"if,omitempty"`
+	Then                 *Schema            `json:"then,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+}
+
+// Provider is the interface that is implemented by the types which describe themselves.
+// Reflector uses the schema returned by JSONSchema instead of reflecting the type.
+type Provider interface {
+	JSONSchema() *Schema
+}
+
+var (
+	providerType = reflect.TypeOf((*Provider)(nil)).Elem()
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// Duration returns the schema of time.Duration which is written as a string such as "30s".
+func Duration() *Schema {
+	return &Schema{
+		Type:    "string",
+		Pattern: durationPattern,
+	}
+}
+
+// Reflect returns the schema of the type of v based on the yaml struct tags.
+// The nested structs are inlined.
+func Reflect(v interface{}) *Schema {
+	return (&Reflector{}).Reflect(reflect.TypeOf(v))
+}
+
+// Reflector creates schemas of Go types based on the yaml struct tags.
+type Reflector struct {
+	// Definitions holds the schemas of the named structs if it is not nil.
+	// The structs are referred as "#/definitions/TypeName" instead of being inlined,
+	// which enables to describe recursive types.
+	Definitions map[string]*Schema
+}
+
+// Reflect returns the schema of t.
+// The top-level struct is always inlined.
+func (r *Reflector) Reflect(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := provide(t); ok {
+		return s
+	}
+	if t.Kind() == reflect.Struct {
+		return r.reflectStruct(t)
+	}
+	return r.reflect(t)
+}
+
+func (r *Reflector) reflect(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if s, ok := provide(t); ok {
+		return s
+	}
+	if t == durationType {
+		return Duration()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{
+			Type:  "array",
+			Items: r.reflect(t.Elem()),
+		}
+	case reflect.Map:
+		return &Schema{
+			Type:                 "object",
+			AdditionalProperties: r.reflect(t.Elem()),
+		}
+	case reflect.Struct:
+		if r.Definitions == nil || t.Name() == "" {
+			return r.reflectStruct(t)
+		}
+		if _, ok := r.Definitions[t.Name()]; !ok {
+			// register before reflecting the fields for recursive types
+			r.Definitions[t.Name()] = &Schema{}
+			*r.Definitions[t.Name()] = *r.reflectStruct(t)
+		}
+		return &Schema{Ref: "#/definitions/" + t.Name()}
+	}
+	// interface{} accepts any values
+	return &Schema{}
+}
+
+func (r *Reflector) reflectStruct(t reflect.Type) *Schema {
+	s := &Schema{
+		Type:                 "object",
+		Properties:           map[string]*Schema{},
+		AdditionalProperties: false,
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" && !f.Anonymous {
+			continue // unexported
+		}
+		name, opts := parseTag(f)
+		if name == "-" {
+			continue
+		}
+		if opts["inline"] && f.Type.Kind() == reflect.Struct {
+			for k, v := range r.reflectStruct(f.Type).Properties {
+				s.Properties[k] = v
+			}
+			continue
+		}
+		s.Properties[name] = r.reflect(f.Type)
+	}
+	return s
+}
+
+func provide(t reflect.Type) (*Schema, bool) {
+	if t.Kind() == reflect.Interface {
+		return nil, false
+	}
+	if t.Implements(providerType) {
+		return reflect.Zero(t).Interface().(Provider).JSONSchema(), true
+	}
+	if reflect.PtrTo(t).Implements(providerType) {
+		return reflect.New(t).Interface().(Provider).JSONSchema(), true
+	}
+	return nil, false
+}
+
+// parseTag returns the field name and options of the yaml tag.
+// The name is the lower-cased field name if the tag has no name like the YAML decoder.
+func parseTag(f reflect.StructField) (string, map[string]bool) {
+	opts := map[string]bool{}
+	parts := strings.Split(f.Tag.Get("yaml"), ",")
+	for _, o := range parts[1:] {
+		opts[o] = true
+	}
+	if parts[0] != "" {
+		return parts[0], opts
+	}
+	return strings.ToLower(f.Name), opts
+}
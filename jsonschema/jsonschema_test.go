@@ -0,0 +1,88 @@
+package jsonschema
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type testNode struct {
+	Name     string        `yaml:"name"`
+	Children []*testNode   `yaml:"children"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+type testProvided struct{}
+
+func (testProvided) JSONSchema() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func TestReflect(t *testing.T) {
+	type embedded struct {
+		Embedded bool `yaml:"embedded"`
+	}
+	type nested struct {
+		Value float64 `yaml:"value"`
+	}
+	type sample struct {
+		embedded   `yaml:",inline"`
+		Default    string
+		Renamed    int    `yaml:"renamed,omitempty"`
+		Ignored    string `yaml:"-"`
+		unexported string
+		Nested     *nested                `yaml:"nested"`
+		Map        map[string]interface{} `yaml:"map"`
+		Provided   testProvided           `yaml:"provided"`
+	}
+	got := Reflect(sample{})
+	expect := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"embedded": {Type: "boolean"},
+			"default":  {Type: "string"},
+			"renamed":  {Type: "integer"},
+			"nested": {
+				Type: "object",
+				Properties: map[string]*Schema{
+					"value": {Type: "number"},
+				},
+				AdditionalProperties: false,
+			},
+			"map": {
+				Type:                 "object",
+				AdditionalProperties: &Schema{},
+			},
+			"provided": {Type: "string"},
+		},
+		AdditionalProperties: false,
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
+
+func TestReflector_Definitions(t *testing.T) {
+	r := &Reflector{Definitions: map[string]*Schema{}}
+	got := r.Reflect(reflect.TypeOf(testNode{}))
+	node := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"name": {Type: "string"},
+			"children": {
+				Type:  "array",
+				Items: &Schema{Ref: "#/definitions/testNode"},
+			},
+			"timeout": Duration(),
+		},
+		AdditionalProperties: false,
+	}
+	if diff := cmp.Diff(node, got); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]*Schema{"testNode": node}, r.Definitions); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
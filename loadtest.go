@@ -0,0 +1,306 @@
+package scenarigo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+// LoadTestOptions represents the options of the load test.
+type LoadTestOptions struct {
+	// Concurrency is the number of workers which run the scenarios simultaneously. The default is 1.
+	Concurrency int
+	// Duration is the time to keep starting new iterations.
+	Duration time.Duration
+	// Iterations is the total number of iterations. An iteration runs all scenarios once in order.
+	Iterations int
+}
+
+func (o LoadTestOptions) validate() error {
+	if o.Concurrency < 0 {
+		return errors.Errorf("concurrency must not be negative but got %d", o.Concurrency)
+	}
+	if o.Duration < 0 {
+		return errors.Errorf("duration must not be negative but got %s", o.Duration)
+	}
+	if o.Iterations < 0 {
+		return errors.Errorf("iterations must not be negative but got %d", o.Iterations)
+	}
+	if o.Duration == 0 && o.Iterations == 0 {
+		return errors.New("duration or iterations must be specified")
+	}
+	return nil
+}
+
+// LoadTestResult represents the result of the load test.
+type LoadTestResult struct {
+	Duration   time.Duration           `json:"duration"`
+	Iterations int                     `json:"iterations"`
+	Failures   int                     `json:"failures"`   // iterations in which some scenarios failed
+	Throughput float64                 `json:"throughput"` // iterations per second
+	Steps      []*LoadTestStepResult   `json:"steps"`
+	Setup      []*ScenarioResult       `json:"setup,omitempty"`
+	Teardown   []*ScenarioResult       `json:"teardown,omitempty"`
+	steps      map[string]*stepLatency // aggregated by the name
+}
+
+// Failed reports whether some iterations or hooks failed.
+func (r *LoadTestResult) Failed() bool {
+	if r.Failures > 0 {
+		return true
+	}
+	for _, res := range append(r.Setup, r.Teardown...) {
+		if res.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTestStepResult represents the statistics of a step in the load test.
+type LoadTestStepResult struct {
+	Name       string        `json:"name"` // "file/scenario/step"
+	Count      int           `json:"count"`
+	Errors     int           `json:"errors"`
+	ErrorRate  float64       `json:"errorRate"`
+	Throughput float64       `json:"throughput"` // requests per second
+	Latency    LatencyResult `json:"latency"`
+}
+
+// LatencyResult represents the statistics of the latencies.
+type LatencyResult struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
+}
+
+type stepLatency struct {
+	durations []time.Duration
+	errors    int
+}
+
+// loadTestScenario represents a scenario to run in the load test.
+type loadTestScenario struct {
+	name     string
+	scenario *schema.Scenario
+	params   map[string]interface{}
+}
+
+// LoadTest runs the scenarios repeatedly with the options and returns the statistics of each step.
+// The setup and teardown hooks are run only once before and after the load test.
+// If a setup hook failed, no iterations are run but the teardown hooks are still run.
+// The test reporter of ctx reports only the failures to load the scenarios and the hooks.
+func (r *Runner) LoadTest(ctx *context.Context, opts LoadTestOptions) (*LoadTestResult, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	if opts.Concurrency == 0 {
+		opts.Concurrency = 1
+	}
+	scns, err := r.loadTestScenarios()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LoadTestResult{
+		Steps: []*LoadTestStepResult{},
+		steps: map[string]*stepLatency{},
+	}
+	ctx = r.prepareContext(ctx)
+	setupOK := true
+	if len(r.setup) > 0 {
+		setupCtx, results, ok := runHooks(ctx, "setup", r.setup, false)
+		result.Setup = results
+		setupOK = ok
+		ctx = setupCtx.WithReporter(ctx.Reporter()).WithRequestContext(ctx.RequestContext())
+	}
+	if setupOK {
+		runLoadTestIterations(ctx, scns, opts, result)
+	}
+	if len(r.teardown) > 0 {
+		_, results, _ := runHooks(ctx, "teardown", r.teardown, true)
+		result.Teardown = results
+	}
+	return result, nil
+}
+
+// runLoadTestIterations runs the iterations of scns concurrently and aggregates the results.
+func runLoadTestIterations(ctx *context.Context, scns []*loadTestScenario, opts LoadTestOptions, result *LoadTestResult) {
+	var (
+		m         sync.Mutex
+		wg        sync.WaitGroup
+		remaining = opts.Iterations
+	)
+	start := time.Now()
+	next := func() bool {
+		m.Lock()
+		defer m.Unlock()
+		if opts.Duration > 0 && time.Since(start) >= opts.Duration {
+			return false
+		}
+		if ctx.RequestContext().Err() != nil {
+			return false
+		}
+		if opts.Iterations > 0 {
+			if remaining == 0 {
+				return false
+			}
+			remaining--
+		}
+		return true
+	}
+	for i := 0; i < opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for next() {
+				var failed bool
+				for _, scn := range scns {
+					res := runLoadTestScenario(ctx, scn)
+					if res.Status == StatusFailed {
+						failed = true
+					}
+					m.Lock()
+					result.add(scn.name, res)
+					m.Unlock()
+				}
+				m.Lock()
+				result.Iterations++
+				if failed {
+					result.Failures++
+				}
+				m.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Duration = time.Since(start)
+	result.summarize()
+}
+
+// loadTestScenarios loads the scenarios to run in the load test.
+func (r *Runner) loadTestScenarios() ([]*loadTestScenario, error) {
+	var scns []*loadTestScenario
+	for _, f := range r.scenarioFiles {
+		loaded, err := schema.LoadScenarios(f)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to load scenarios")
+		}
+		for _, scn := range r.filterScenarios(f, loaded) {
+			name := fmt.Sprintf("%s/%s", f, scn.Title)
+			if scn.ParameterSets() == nil {
+				scns = append(scns, &loadTestScenario{name: name, scenario: scn})
+				continue
+			}
+			for _, set := range r.parameterSets(f, scn) {
+				scns = append(scns, &loadTestScenario{
+					name:     fmt.Sprintf("%s/%s", name, set.name),
+					scenario: set.scenario,
+					params:   set.params,
+				})
+			}
+		}
+	}
+	if len(scns) == 0 {
+		return nil, errors.New("no scenarios to run")
+	}
+	return scns, nil
+}
+
+// runLoadTestScenario runs scn with a new reporter which discards the output.
+func runLoadTestScenario(ctx *context.Context, scn *loadTestScenario) *ScenarioResult {
+	result := newScenarioResult(scn.scenario, scn.params)
+	reporter.Run(func(rptr reporter.Reporter) {
+		runScenarioWithResult(ctx.WithReporter(rptr), scn.scenario, scn.params, result)
+	})
+	return result
+}
+
+// add aggregates the result of a scenario.
+func (r *LoadTestResult) add(name string, res *ScenarioResult) {
+	var walk func(prefix string, steps []*StepResult)
+	walk = func(prefix string, steps []*StepResult) {
+		for _, step := range steps {
+			stepName := fmt.Sprintf("%s/%s", prefix, step.Title)
+			if len(step.Steps) > 0 {
+				walk(stepName, step.Steps)
+				continue
+			}
+			if step.Status != StatusPassed && step.Status != StatusFailed {
+				continue
+			}
+			l, ok := r.steps[stepName]
+			if !ok {
+				l = &stepLatency{}
+				r.steps[stepName] = l
+				r.Steps = append(r.Steps, &LoadTestStepResult{Name: stepName})
+			}
+			l.durations = append(l.durations, step.Duration)
+			if step.Status == StatusFailed {
+				l.errors++
+			}
+		}
+	}
+	walk(name, res.Steps)
+	walk(name, res.Teardown)
+}
+
+// summarize calculates the statistics.
+func (r *LoadTestResult) summarize() {
+	secs := r.Duration.Seconds()
+	if secs > 0 {
+		r.Throughput = float64(r.Iterations) / secs
+	}
+	for _, step := range r.Steps {
+		l := r.steps[step.Name]
+		step.Count = len(l.durations)
+		step.Errors = l.errors
+		step.ErrorRate = float64(l.errors) / float64(step.Count)
+		if secs > 0 {
+			step.Throughput = float64(step.Count) / secs
+		}
+		step.Latency = latencyOf(l.durations)
+	}
+}
+
+func latencyOf(durations []time.Duration) LatencyResult {
+	if len(durations) == 0 {
+		return LatencyResult{}
+	}
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return LatencyResult{
+		Min:  sorted[0],
+		Mean: total / time.Duration(len(sorted)),
+		P50:  percentile(sorted, 50),
+		P90:  percentile(sorted, 90),
+		P95:  percentile(sorted, 95),
+		P99:  percentile(sorted, 99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+// percentile returns the p-th percentile of the sorted durations by the nearest-rank method.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
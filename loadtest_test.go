@@ -0,0 +1,232 @@
+package scenarigo
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/protocol"
+	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+func TestRunner_LoadTest(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				if req == "ng" {
+					return ctx, nil, errors.New("some error occurred")
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/continue-on-error.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *LoadTestResult
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result, err = r.LoadTest(context.New(rptr), LoadTestOptions{
+			Concurrency: 2,
+			Iterations:  5,
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !ok {
+		t.Fatal("failed")
+	}
+	if got, expect := result.Iterations, 5; got != expect {
+		t.Errorf("expect %d iterations but got %d", expect, got)
+	}
+	// each iteration runs two failed scenarios
+	if got, expect := result.Failures, 5; got != expect {
+		t.Errorf("expect %d failures but got %d", expect, got)
+	}
+	if !result.Failed() {
+		t.Error("expect failed")
+	}
+
+	type stat struct {
+		Name   string
+		Count  int
+		Errors int
+	}
+	var got []stat
+	for _, step := range result.Steps {
+		got = append(got, stat{step.Name, step.Count, step.Errors})
+		if step.Latency.Min > step.Latency.P50 || step.Latency.P50 > step.Latency.Max {
+			t.Errorf("%s: invalid latency: %+v", step.Name, step.Latency)
+		}
+	}
+	// the skipped steps are not counted
+	expect := []stat{
+		{"testdata/scenarios/continue-on-error.yaml/continue on error step/soft failure", 5, 5},
+		{"testdata/scenarios/continue-on-error.yaml/continue on error step/run", 5, 0},
+		{"testdata/scenarios/continue-on-error.yaml/continue on error step/hard failure", 5, 5},
+		{"testdata/scenarios/continue-on-error.yaml/continue on error scenario/failure 1", 5, 5},
+		{"testdata/scenarios/continue-on-error.yaml/continue on error scenario/failure 2", 5, 5},
+		{"testdata/scenarios/continue-on-error.yaml/continue on error scenario/run", 5, 0},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("steps differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_LoadTest_Duration(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		invoker: invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return ctx, nil, nil
+		}),
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/if.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *LoadTestResult
+	reporter.Run(func(rptr reporter.Reporter) {
+		result, err = r.LoadTest(context.New(rptr), LoadTestOptions{
+			Duration: 50 * time.Millisecond,
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if result.Iterations == 0 {
+		t.Error("no iterations")
+	}
+	if result.Duration < 50*time.Millisecond {
+		t.Errorf("finished too early: %s", result.Duration)
+	}
+}
+
+func TestRunner_LoadTest_SetupFailed(t *testing.T) {
+	var requests []interface{}
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				requests = append(requests, req)
+				if req == "ng" {
+					return ctx, nil, errors.New("some error occurred")
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(
+		WithScenarios("testdata/hooks/scenario.yaml"),
+		WithSetup(&schema.Hook{Scenario: "testdata/hooks/setup-fail.yaml"}),
+		WithTeardown(&schema.Hook{Scenario: "testdata/hooks/teardown.yaml"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *LoadTestResult
+	reporter.Run(func(rptr reporter.Reporter) {
+		result, err = r.LoadTest(context.New(rptr), LoadTestOptions{
+			Iterations: 5,
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !result.Failed() {
+		t.Error("expect failed")
+	}
+	if got := result.Iterations; got != 0 {
+		t.Errorf("expect no iterations but got %d", got)
+	}
+	if got := len(result.Teardown); got != 1 {
+		t.Errorf("expect teardown result but got %d results", got)
+	}
+	// the teardown hook is run even though the setup hook failed
+	if diff := cmp.Diff([]interface{}{"ng", "delete {{vars.tenant}}"}, requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_LoadTest_InvalidOptions(t *testing.T) {
+	tests := map[string]struct {
+		opts   LoadTestOptions
+		expect string
+	}{
+		"no limits": {
+			opts:   LoadTestOptions{Concurrency: 1},
+			expect: "duration or iterations must be specified",
+		},
+		"negative concurrency": {
+			opts:   LoadTestOptions{Concurrency: -1, Iterations: 1},
+			expect: "concurrency must not be negative but got -1",
+		},
+		"negative duration": {
+			opts:   LoadTestOptions{Duration: -time.Second},
+			expect: "duration must not be negative but got -1s",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRunner(WithScenarios("testdata/scenarios/if.yaml"))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			reporter.Run(func(rptr reporter.Reporter) {
+				_, err = r.LoadTest(context.New(rptr), test.opts)
+			})
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if got := err.Error(); got != test.expect {
+				t.Errorf("expect %q but got %q", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestLatencyOf(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		// 100ms, 99ms, ..., 1ms
+		durations[i] = time.Duration(100-i) * time.Millisecond
+	}
+	expect := LatencyResult{
+		Min:  time.Millisecond,
+		Mean: 50500 * time.Microsecond,
+		P50:  50 * time.Millisecond,
+		P90:  90 * time.Millisecond,
+		P95:  95 * time.Millisecond,
+		P99:  99 * time.Millisecond,
+		Max:  100 * time.Millisecond,
+	}
+	if diff := cmp.Diff(expect, latencyOf(durations)); diff != "" {
+		t.Errorf("differ (-want +got):\n%s", diff)
+	}
+	if got := latencyOf(nil); got != (LatencyResult{}) {
+		t.Errorf("expect zero but got %+v", got)
+	}
+}
@@ -0,0 +1,40 @@
+package scenarigo
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+// loopVars returns the variables of each iteration of step such as {"index": 0, "item": "foo"}.
+// It returns nil if step is not a loop.
+func loopVars(ctx *context.Context, step *schema.Step) ([]map[string]interface{}, error) {
+	switch {
+	case step.Repeat > 0:
+		vars := make([]map[string]interface{}, step.Repeat)
+		for i := range vars {
+			vars[i] = map[string]interface{}{"index": i}
+		}
+		return vars, nil
+	case step.Foreach != nil:
+		x, err := ctx.ExecuteTemplate(step.Foreach)
+		if err != nil {
+			return nil, err
+		}
+		v := reflect.ValueOf(x)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, errors.Errorf("foreach must be a list but got %T", x)
+		}
+		vars := make([]map[string]interface{}, v.Len())
+		for i := range vars {
+			vars[i] = map[string]interface{}{
+				"index": i,
+				"item":  v.Index(i).Interface(),
+			}
+		}
+		return vars, nil
+	}
+	return nil, nil
+}
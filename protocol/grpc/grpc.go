@@ -1,6 +1,9 @@
 package grpc
 
-import "github.com/zoncoen/scenarigo/protocol"
+import (
+	"github.com/zoncoen/scenarigo/jsonschema"
+	"github.com/zoncoen/scenarigo/protocol"
+)
 
 func init() {
 	protocol.Register(&GRPC{})
@@ -31,3 +34,16 @@ func (p *GRPC) UnmarshalExpect(unmarshal func(interface{}) error) (protocol.Asse
 	}
 	return &e, nil
 }
+
+// RequestSchema implements protocol.SchemaProvider interface.
+func (p *GRPC) RequestSchema() *jsonschema.Schema {
+	return jsonschema.Reflect(Request{})
+}
+
+// ExpectSchema implements protocol.SchemaProvider interface.
+func (p *GRPC) ExpectSchema() *jsonschema.Schema {
+	s := jsonschema.Reflect(Expect{})
+	// the status code such as "NotFound" or 5
+	s.Properties["code"].Type = []string{"string", "integer"}
+	return s
+}
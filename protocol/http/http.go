@@ -1,6 +1,11 @@
 package http
 
-import "github.com/zoncoen/scenarigo/protocol"
+import (
+	"net/http"
+
+	"github.com/zoncoen/scenarigo/jsonschema"
+	"github.com/zoncoen/scenarigo/protocol"
+)
 
 func init() {
 	protocol.Register(&HTTP{})
@@ -31,3 +36,21 @@ func (p *HTTP) UnmarshalExpect(unmarshal func(interface{}) error) (protocol.Asse
 	}
 	return &e, nil
 }
+
+// RequestSchema implements protocol.SchemaProvider interface.
+func (p *HTTP) RequestSchema() *jsonschema.Schema {
+	s := jsonschema.Reflect(Request{})
+	s.Properties["method"].Enum = []interface{}{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+	}
+	return s
+}
+
+// ExpectSchema implements protocol.SchemaProvider interface.
+func (p *HTTP) ExpectSchema() *jsonschema.Schema {
+	s := jsonschema.Reflect(Expect{})
+	// the status code such as 200 or "OK"
+	s.Properties["code"].Type = []string{"string", "integer"}
+	return s
+}
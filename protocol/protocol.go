@@ -2,11 +2,13 @@
 package protocol
 
 import (
+	"sort"
 	"strings"
 	"sync"
 
 	"github.com/zoncoen/scenarigo/assert"
 	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/jsonschema"
 )
 
 var (
@@ -39,6 +41,22 @@ func Get(name string) Protocol {
 	return p
 }
 
+// List returns the registered protocols sorted by name.
+func List() []Protocol {
+	m.Lock()
+	defer m.Unlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ps := make([]Protocol, len(names))
+	for i, name := range names {
+		ps[i] = registry[name]
+	}
+	return ps
+}
+
 // Protocol is the interface that creates Invoker and AssertionBuilder from YAML.
 type Protocol interface {
 	Name() string
@@ -55,3 +73,9 @@ type Invoker interface {
 type AssertionBuilder interface {
 	Build(*context.Context) (assert.Assertion, error)
 }
+
+// SchemaProvider is the optional interface that a Protocol implements to describe the request and expect in the JSON Schema of scenario files.
+type SchemaProvider interface {
+	RequestSchema() *jsonschema.Schema
+	ExpectSchema() *jsonschema.Schema
+}
@@ -16,6 +16,14 @@ func WithMaxParallel(i int) Option {
 	}
 }
 
+// RaiseMaxParallel raises the number of parallel of the tests which share the context with r to n.
+// It does nothing if the number is already greater than or equal to n, or r is not created by Run.
+func RaiseMaxParallel(r Reporter, n int) {
+	if r, ok := r.(*reporter); ok {
+		r.context.raiseMaxParallel(n)
+	}
+}
+
 // WithWriter returns an option to set the writer.
 func WithWriter(w io.Writer) Option {
 	return func(ctx *testContext) {
@@ -68,6 +76,20 @@ func (c *testContext) waitParallel() {
 	<-c.startParallel
 }
 
+func (c *testContext) raiseMaxParallel(n int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	if n <= c.maxParallel {
+		return
+	}
+	c.maxParallel = n
+	for c.numWaiting > 0 && c.running < c.maxParallel {
+		c.numWaiting--
+		c.running++
+		c.startParallel <- true // Pick a waiting test to be run.
+	}
+}
+
 func (c *testContext) release() {
 	c.m.Lock()
 	if c.numWaiting == 0 {
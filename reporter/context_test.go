@@ -62,4 +62,32 @@ func TestTestContext(t *testing.T) {
 
 		<-done
 	})
+	t.Run("raise max parallel", func(t *testing.T) {
+		ctx := newTestContext(WithMaxParallel(1))
+
+		done := make(chan struct{})
+		go func() {
+			ctx.waitParallel() // wait until the number of parallel is raised
+			close(done)
+		}()
+
+		time.Sleep(100 * time.Millisecond)
+		ctx.raiseMaxParallel(2)
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("waiting test is not started")
+		}
+		if expect, got := 2, ctx.running; got != expect {
+			t.Errorf("expected %d but got %d", expect, got)
+		}
+		if expect, got := 0, ctx.numWaiting; got != expect {
+			t.Errorf("expected %d but got %d", expect, got)
+		}
+
+		ctx.raiseMaxParallel(1)
+		if expect, got := 2, ctx.maxParallel; got != expect {
+			t.Errorf("expected %d but got %d", expect, got)
+		}
+	})
 }
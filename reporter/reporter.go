@@ -195,6 +195,12 @@ func (r *reporter) appendChild(child *reporter) {
 	r.m.Unlock()
 }
 
+// SubtestName returns the name which is used by Reporter.Run for the subtest called name.
+// It rewrites name to having only printable characters and no white space.
+func SubtestName(name string) string {
+	return rewrite(name)
+}
+
 // rewrite rewrites a subname to having only printable characters and no white space.
 func rewrite(s string) string {
 	b := make([]byte, 0, len(s))
@@ -0,0 +1,191 @@
+package scenarigo
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/k0kubun/pp"
+	yamljson "github.com/kubernetes-sigs/yaml"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/yaml"
+)
+
+// Status represents a status of a test result.
+type Status string
+
+// statuses
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+func statusOf(r reporter.Reporter) Status {
+	switch {
+	case r.Failed():
+		return StatusFailed
+	case r.Skipped():
+		return StatusSkipped
+	}
+	return StatusPassed
+}
+
+// Result represents a result of Runner.Run.
+type Result struct {
+	Status   Status            `json:"status"`
+	Duration time.Duration     `json:"duration"`
+	Setup    []*ScenarioResult `json:"setup,omitempty"`
+	Files    []*FileResult     `json:"files"`
+	Teardown []*ScenarioResult `json:"teardown,omitempty"`
+}
+
+// Failed reports whether some scenarios failed.
+func (r *Result) Failed() bool {
+	return r.Status == StatusFailed
+}
+
+// FileResult represents a result of a scenario file.
+type FileResult struct {
+	Name      string            `json:"name"`
+	Status    Status            `json:"status"`
+	Duration  time.Duration     `json:"duration"`
+	Logs      []string          `json:"logs,omitempty"`
+	Scenarios []*ScenarioResult `json:"scenarios"`
+}
+
+// ScenarioResult represents a result of a scenario.
+type ScenarioResult struct {
+	Title      string                 `json:"title"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Status     Status                 `json:"status"`
+	Duration   time.Duration          `json:"duration"`
+	Logs       []string               `json:"logs,omitempty"`
+	Steps      []*StepResult          `json:"steps"`
+	Teardown   []*StepResult          `json:"teardown,omitempty"`
+}
+
+// StepResult represents a result of a step.
+type StepResult struct {
+	Title    string        `json:"title"`
+	Status   Status        `json:"status"`
+	Duration time.Duration `json:"duration"`
+	Logs     []string      `json:"logs,omitempty"`
+	// Request and Response are not masked because they are masked only when printed.
+	// Use MaskedRequest and MaskedResponse to hide the secrets.
+	Request  interface{}   `json:"request,omitempty"`
+	Response interface{}   `json:"response,omitempty"`
+	Steps    []*StepResult `json:"steps,omitempty"`
+	// Include is the result of the included scenario.
+	Include *ScenarioResult `json:"include,omitempty"`
+
+	masker *context.Masker
+}
+
+// MaskedRequest returns a copy of the request whose secret values and the values of the masked fields are replaced with context.MaskedValue.
+func (r *StepResult) MaskedRequest() interface{} {
+	return r.masker.MaskValue(r.Request)
+}
+
+// MaskedResponse returns a copy of the response whose secret values and the values of the masked fields are replaced with context.MaskedValue.
+func (r *StepResult) MaskedResponse() interface{} {
+	return r.masker.MaskValue(r.Response)
+}
+
+type stepResult StepResult
+
+// MarshalJSON implements json.Marshaler interface.
+// The request and response are masked and converted via YAML because they may contain maps which have non-string keys.
+func (r *StepResult) MarshalJSON() ([]byte, error) {
+	v := struct {
+		*stepResult
+		Request  json.RawMessage `json:"request,omitempty"`
+		Response json.RawMessage `json:"response,omitempty"`
+	}{
+		stepResult: (*stepResult)(r),
+	}
+	if req := r.MaskedRequest(); req != nil {
+		v.Request = toJSON(req)
+	}
+	if resp := r.MaskedResponse(); resp != nil {
+		v.Response = toJSON(resp)
+	}
+	return json.Marshal(v)
+}
+
+func toJSON(v interface{}) json.RawMessage {
+	if b, err := yaml.Marshal(v); err == nil {
+		if j, err := yamljson.YAMLToJSON(b); err == nil {
+			return j
+		}
+	}
+	b, _ := json.Marshal(pp.Sprint(v))
+	return b
+}
+
+// logRecorder is a reporter which records its logs.
+// The secret values in the logs are masked before recording and reporting.
+type logRecorder struct {
+	reporter.Reporter
+	m      sync.Mutex
+	masker *context.Masker
+	logs   *[]string
+}
+
+func newLogRecorder(ctx *context.Context, logs *[]string) *logRecorder {
+	return &logRecorder{
+		Reporter: ctx.Reporter(),
+		masker:   ctx.Masker(),
+		logs:     logs,
+	}
+}
+
+func (r *logRecorder) record(s string) string {
+	s = r.masker.MaskString(s)
+	r.m.Lock()
+	*r.logs = append(*r.logs, s)
+	r.m.Unlock()
+	return s
+}
+
+// Log implements reporter.Reporter interface.
+func (r *logRecorder) Log(args ...interface{}) {
+	r.Reporter.Log(r.record(fmt.Sprint(args...)))
+}
+
+// Logf implements reporter.Reporter interface.
+func (r *logRecorder) Logf(format string, args ...interface{}) {
+	r.Reporter.Log(r.record(fmt.Sprintf(format, args...)))
+}
+
+// Error implements reporter.Reporter interface.
+func (r *logRecorder) Error(args ...interface{}) {
+	r.Reporter.Error(r.record(fmt.Sprint(args...)))
+}
+
+// Errorf implements reporter.Reporter interface.
+func (r *logRecorder) Errorf(format string, args ...interface{}) {
+	r.Reporter.Error(r.record(fmt.Sprintf(format, args...)))
+}
+
+// Fatal implements reporter.Reporter interface.
+func (r *logRecorder) Fatal(args ...interface{}) {
+	r.Reporter.Fatal(r.record(fmt.Sprint(args...)))
+}
+
+// Fatalf implements reporter.Reporter interface.
+func (r *logRecorder) Fatalf(format string, args ...interface{}) {
+	r.Reporter.Fatal(r.record(fmt.Sprintf(format, args...)))
+}
+
+// Skip implements reporter.Reporter interface.
+func (r *logRecorder) Skip(args ...interface{}) {
+	r.Reporter.Skip(r.record(fmt.Sprint(args...)))
+}
+
+// Skipf implements reporter.Reporter interface.
+func (r *logRecorder) Skipf(format string, args ...interface{}) {
+	r.Reporter.Skip(r.record(fmt.Sprintf(format, args...)))
+}
@@ -0,0 +1,57 @@
+package scenarigo
+
+import (
+	"time"
+
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+const defaultRetryInterval = time.Second
+
+// retry calls f until it succeeds or the retry policy gives up.
+// It returns the context and the error of the last attempt with the number of attempts.
+func retry(ctx *context.Context, policy *schema.RetryPolicy, f func(*context.Context) (*context.Context, error)) (*context.Context, int, error) {
+	if policy == nil {
+		newCtx, err := f(ctx)
+		return newCtx, 1, err
+	}
+
+	start := time.Now()
+	interval := policy.Interval
+	if interval == 0 {
+		interval = defaultRetryInterval
+	}
+	var (
+		lastCtx *context.Context
+		err     error
+	)
+	for attempt := 1; ; attempt++ {
+		lastCtx, err = f(ctx)
+		if err == nil {
+			return lastCtx, attempt, nil
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return lastCtx, attempt, err
+		}
+		if policy.MaxElapsedTime > 0 && time.Since(start)+interval > policy.MaxElapsedTime {
+			return lastCtx, attempt, err
+		}
+		select {
+		case <-time.After(interval):
+		case <-ctx.RequestContext().Done():
+			return lastCtx, attempt, err
+		}
+		interval = nextRetryInterval(policy, interval)
+	}
+}
+
+func nextRetryInterval(policy *schema.RetryPolicy, interval time.Duration) time.Duration {
+	if policy.Multiplier > 1 {
+		interval = time.Duration(float64(interval) * policy.Multiplier)
+	}
+	if policy.MaxInterval > 0 && interval > policy.MaxInterval {
+		interval = policy.MaxInterval
+	}
+	return interval
+}
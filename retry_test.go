@@ -0,0 +1,40 @@
+package scenarigo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+func TestNextRetryInterval(t *testing.T) {
+	tests := map[string]struct {
+		policy   *schema.RetryPolicy
+		interval time.Duration
+		expect   time.Duration
+	}{
+		"constant": {
+			policy:   &schema.RetryPolicy{},
+			interval: time.Second,
+			expect:   time.Second,
+		},
+		"exponential": {
+			policy:   &schema.RetryPolicy{Multiplier: 1.5},
+			interval: time.Second,
+			expect:   1500 * time.Millisecond,
+		},
+		"max interval": {
+			policy:   &schema.RetryPolicy{Multiplier: 2, MaxInterval: 3 * time.Second},
+			interval: 2 * time.Second,
+			expect:   3 * time.Second,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if got := nextRetryInterval(test.policy, test.interval); got != test.expect {
+				t.Errorf("expect %s but got %s", test.expect, got)
+			}
+		})
+	}
+}
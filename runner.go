@@ -1,12 +1,17 @@
 package scenarigo
 
 import (
-	"io/ioutil"
-	"os"
+	"fmt"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/internal/tagexpr"
+	"github.com/zoncoen/scenarigo/reporter"
 	"github.com/zoncoen/scenarigo/schema"
 
 	// register default protocols
@@ -17,7 +22,20 @@ import (
 // Runner represents a test runner.
 type Runner struct {
 	pluginDir     *string
+	scenarioPaths []string
+	extensions    []string
 	scenarioFiles []string
+	vars          map[string]interface{}
+	secrets       map[string]interface{}
+	maskedFields  []string
+	config        *schema.Config
+	profile       string
+	tagExpr       tagexpr.Expr
+	runPattern    *regexp.Regexp
+	maxParallel   int
+	timeout       time.Duration
+	setup         []*schema.Hook
+	teardown      []*schema.Hook
 }
 
 // WithPluginDir returns a option which sets plugin root directory.
@@ -40,65 +58,501 @@ func NewRunner(opts ...func(*Runner) error) (*Runner, error) {
 			return nil, err
 		}
 	}
+	if err := r.applyConfig(); err != nil {
+		return nil, err
+	}
+	if err := r.executeSecrets(); err != nil {
+		return nil, err
+	}
+	if r.extensions == nil {
+		r.extensions = defaultScenarioExtensions
+	}
+	files, err := r.findScenarioFiles(r.scenarioPaths...)
+	if err != nil {
+		return nil, err
+	}
+	r.scenarioFiles = files
 	return r, nil
 }
 
+// WithConfig returns a option which sets the project configuration.
+// The settings which are set by other options take precedence over the configuration.
+func WithConfig(cfg *schema.Config) func(*Runner) error {
+	return func(r *Runner) error {
+		r.config = cfg
+		return nil
+	}
+}
+
+// WithConfigFile returns a option which loads and sets the project configuration file.
+func WithConfigFile(path string) func(*Runner) error {
+	return func(r *Runner) error {
+		cfg, err := schema.LoadConfig(path)
+		if err != nil {
+			return errors.Wrapf(err, `failed to load config "%s"`, path)
+		}
+		r.config = cfg
+		return nil
+	}
+}
+
+// WithProfile returns a option which selects the environment profile of the project configuration.
+func WithProfile(name string) func(*Runner) error {
+	return func(r *Runner) error {
+		r.profile = name
+		return nil
+	}
+}
+
+func (r *Runner) applyConfig() error {
+	if r.config == nil {
+		if r.profile != "" {
+			return errors.Errorf(`failed to select profile "%s": no config`, r.profile)
+		}
+		return nil
+	}
+	cfg := r.config
+	if r.profile != "" {
+		var err error
+		cfg, err = cfg.WithProfile(r.profile)
+		if err != nil {
+			return err
+		}
+	}
+	if r.pluginDir == nil && cfg.PluginDirectory != "" {
+		if err := WithPluginDir(cfg.PluginDirectory)(r); err != nil {
+			return err
+		}
+	}
+	if r.maxParallel == 0 && cfg.MaxParallel > 0 {
+		r.maxParallel = cfg.MaxParallel
+	}
+	if r.timeout == 0 && cfg.Timeout > 0 {
+		r.timeout = cfg.Timeout
+	}
+	if r.setup == nil && len(cfg.Setup) > 0 {
+		r.setup = cfg.Setup
+	}
+	if r.teardown == nil && len(cfg.Teardown) > 0 {
+		r.teardown = cfg.Teardown
+	}
+	if r.scenarioPaths == nil && len(cfg.Scenarios) > 0 {
+		if err := WithScenarios(cfg.Scenarios...)(r); err != nil {
+			return err
+		}
+	}
+	if cfg.Vars != nil {
+		r.vars = schema.MergeVars(cfg.Vars, r.vars)
+	}
+	if cfg.Secrets != nil {
+		r.secrets = schema.MergeVars(cfg.Secrets, r.secrets)
+	}
+	r.maskedFields = append(append([]string{}, cfg.MaskedFields...), r.maskedFields...)
+	return nil
+}
+
+// WithVars returns a option which sets the variables of the root context.
+// The variables override the variables of the project configuration and the variables set by previous options.
+func WithVars(vars map[string]interface{}) func(*Runner) error {
+	return func(r *Runner) error {
+		r.vars = schema.MergeVars(r.vars, vars)
+		return nil
+	}
+}
+
+// WithVarsFiles returns a option which loads the variables of the root context from the YAML, JSON or .env files.
+// The variables of the later files override the earlier ones like WithVars.
+func WithVarsFiles(paths ...string) func(*Runner) error {
+	return func(r *Runner) error {
+		vars, err := schema.LoadVarsFiles(paths...)
+		if err != nil {
+			return err
+		}
+		r.vars = schema.MergeVars(r.vars, vars)
+		return nil
+	}
+}
+
+// WithSecrets returns a option which sets the secrets of the root context.
+// The secrets are referred as "{{secrets.name}}" and their values are masked in the logs and the test results.
+// The values can be templates which refer environment variables such as "{{env.API_TOKEN}}".
+func WithSecrets(secrets map[string]interface{}) func(*Runner) error {
+	return func(r *Runner) error {
+		r.secrets = schema.MergeVars(r.secrets, secrets)
+		return nil
+	}
+}
+
+// WithSecretsFiles returns a option which loads the secrets of the root context from the YAML, JSON or .env files.
+func WithSecretsFiles(paths ...string) func(*Runner) error {
+	return func(r *Runner) error {
+		secrets, err := schema.LoadVarsFiles(paths...)
+		if err != nil {
+			return err
+		}
+		r.secrets = schema.MergeVars(r.secrets, secrets)
+		return nil
+	}
+}
+
+// WithMaskedFields returns a option which masks the values of the fields such as HTTP headers and body fields in the logs and the test results.
+// The fields of context.DefaultMaskedFields are always masked.
+func WithMaskedFields(names ...string) func(*Runner) error {
+	return func(r *Runner) error {
+		r.maskedFields = append(r.maskedFields, names...)
+		return nil
+	}
+}
+
+// executeSecrets executes the templates of the secrets to get the values from environment variables.
+func (r *Runner) executeSecrets() error {
+	if r.secrets == nil {
+		return nil
+	}
+	x, err := context.New(nil).ExecuteTemplate(r.secrets)
+	if err != nil {
+		return errors.Wrap(err, "invalid secrets")
+	}
+	secrets, ok := x.(map[string]interface{})
+	if !ok {
+		return errors.Errorf("secrets must be a map but got %T", x)
+	}
+	r.secrets = secrets
+	return nil
+}
+
 // WithScenarios returns a option which finds and sets test scenario files.
+// A path can be a file, a directory or a glob pattern such as "scenarios/**/*.yaml".
+// Directories are searched recursively for the files which have the scenario file extensions.
 func WithScenarios(paths ...string) func(*Runner) error {
 	return func(r *Runner) error {
-		files, err := getAllFiles(paths...)
+		r.scenarioPaths = paths
+		return nil
+	}
+}
+
+// WithScenarioExtensions returns a option which sets the file extensions of scenario files
+// to find in directories. The default extensions are ".yaml" and ".yml".
+func WithScenarioExtensions(exts ...string) func(*Runner) error {
+	return func(r *Runner) error {
+		r.extensions = exts
+		return nil
+	}
+}
+
+// WithTags returns a option which selects scenarios by the tag expression such as "smoke && !slow".
+func WithTags(expr string) func(*Runner) error {
+	return func(r *Runner) error {
+		e, err := tagexpr.Parse(expr)
 		if err != nil {
-			return err
+			return errors.Wrapf(err, `invalid tag expression "%s"`, expr)
 		}
-		r.scenarioFiles = files
+		r.tagExpr = e
 		return nil
 	}
 }
 
-func getAllFiles(paths ...string) ([]string, error) {
-	files := []string{}
-	for _, path := range paths {
-		p, err := os.Stat(path)
+// WithRunPattern returns a option which selects scenarios and steps like "go test -run".
+// The regular expression is matched against the "file/scenario/step" path of each step.
+// The path of a scenario with parameters has the name of the parameters such as "file/scenario/locale=en/step".
+// If the path of a scenario matches, all steps of the scenario are run.
+// Otherwise, only the steps whose path match are run.
+func WithRunPattern(pattern string) func(*Runner) error {
+	return func(r *Runner) error {
+		re, err := regexp.Compile(pattern)
 		if err != nil {
-			return nil, err
+			return errors.Wrapf(err, `invalid run pattern "%s"`, pattern)
 		}
-		if p.IsDir() {
-			fis, err := ioutil.ReadDir(path)
-			if err != nil {
-				return nil, err
-			}
-			for _, fi := range fis {
-				fs, err := getAllFiles(filepath.Join(path, fi.Name()))
-				if err != nil {
-					return nil, err
-				}
-				files = append(files, fs...)
-			}
-			continue
+		r.runPattern = re
+		return nil
+	}
+}
+
+// WithMaxParallel returns a option which sets the maximum number of scenarios to run simultaneously.
+// The number of parallel of the reporter is raised to n if it is less than n.
+// If n is 1, all scenarios are run serially.
+// If n is 0, the number is limited by only the reporter.
+func WithMaxParallel(n int) func(*Runner) error {
+	return func(r *Runner) error {
+		if n < 0 {
+			return errors.Errorf("max parallel must not be negative but got %d", n)
 		}
-		files = append(files, path)
+		r.maxParallel = n
+		return nil
+	}
+}
+
+// WithTimeout returns a option which sets the default timeout of each step.
+// The timeout is applied to the steps which have no "timeout" field.
+func WithTimeout(d time.Duration) func(*Runner) error {
+	return func(r *Runner) error {
+		if d < 0 {
+			return errors.Errorf("timeout must not be negative but got %s", d)
+		}
+		r.timeout = d
+		return nil
 	}
-	return files, nil
 }
 
-// Run runs all tests.
-func (r *Runner) Run(ctx *context.Context) {
+// Run runs all tests and returns the result.
+func (r *Runner) Run(ctx *context.Context) *Result {
+	start := time.Now()
+	result := &Result{
+		Status: StatusPassed,
+		Files:  []*FileResult{},
+	}
+	defer func() {
+		result.Duration = time.Since(start)
+	}()
+
+	ctx = r.prepareContext(ctx)
+	setupOK := true
+	if len(r.setup) > 0 {
+		setupCtx, results, ok := runHooks(ctx, "setup", r.setup, false)
+		result.Setup = results
+		setupOK = ok
+		// the following scenarios can access the variables of the setup hooks
+		ctx = setupCtx.WithReporter(ctx.Reporter()).WithRequestContext(ctx.RequestContext())
+	}
+	if setupOK {
+		r.runFiles(ctx, result)
+	} else {
+		result.Status = StatusFailed
+	}
+	if len(r.teardown) > 0 {
+		_, results, ok := runHooks(ctx, "teardown", r.teardown, true)
+		result.Teardown = results
+		if !ok {
+			result.Status = StatusFailed
+		}
+	}
+	return result
+}
+
+// prepareContext returns a copy of ctx with the settings of r.
+func (r *Runner) prepareContext(ctx *context.Context) *context.Context {
 	if r.pluginDir != nil {
 		ctx = ctx.WithPluginDir(*r.pluginDir)
 	}
+	if r.vars != nil {
+		ctx = ctx.WithVars(r.vars)
+	}
+	ctx = ctx.WithMaskedFields(r.maskedFields...).WithSecrets(r.secrets)
+	if r.timeout > 0 {
+		ctx = ctx.WithStepTimeout(r.timeout)
+	}
+	return ctx
+}
+
+// runFiles runs the scenario files and adds the results to result.
+func (r *Runner) runFiles(ctx *context.Context, result *Result) {
+	var sem chan struct{}
+	if r.maxParallel > 1 {
+		// the reporter limits the number of parallel to 1 by default
+		reporter.RaiseMaxParallel(ctx.Reporter(), r.maxParallel)
+		sem = make(chan struct{}, r.maxParallel)
+	}
 	for _, f := range r.scenarioFiles {
-		ctx.Run(f, func(ctx *context.Context) {
-			scns, err := schema.LoadScenarios(f)
+		scns, err := schema.LoadScenarios(f)
+		if err == nil {
+			scns = r.filterScenarios(f, scns)
+			if len(scns) == 0 {
+				continue
+			}
+		}
+		fileResult := &FileResult{
+			Name:      f,
+			Scenarios: []*ScenarioResult{},
+		}
+		result.Files = append(result.Files, fileResult)
+		fileStart := time.Now()
+		ok := ctx.Run(f, func(ctx *context.Context) {
+			ctx = ctx.WithReporter(newLogRecorder(ctx, &fileResult.Logs))
 			if err != nil {
 				ctx.Reporter().Fatalf("failed to load scenarios: %s", err)
 			}
 			for _, scn := range scns {
 				scn := scn
+				paramSets := scn.ParameterSets()
+				if paramSets == nil {
+					scnResult := newScenarioResult(scn, nil)
+					fileResult.Scenarios = append(fileResult.Scenarios, scnResult)
+					ctx.Run(scn.Title, func(ctx *context.Context) {
+						defer r.parallel(ctx, scn, sem)()
+						runScenarioWithResult(ctx, scn, nil, scnResult)
+					})
+					continue
+				}
+
+				// run the scenario for each set of parameters
+				sets := r.parameterSets(f, scn)
+				scnResults := make([]*ScenarioResult, len(sets))
+				for i, set := range sets {
+					scnResults[i] = newScenarioResult(scn, set.params)
+				}
+				fileResult.Scenarios = append(fileResult.Scenarios, scnResults...)
 				ctx.Run(scn.Title, func(ctx *context.Context) {
-					ctx.Reporter().Parallel()
-					_ = runScenario(ctx, scn)
+					defer r.parallel(ctx, scn, sem)()
+					for i, set := range sets {
+						scnResult := scnResults[i]
+						set := set
+						ctx.Run(set.name, func(ctx *context.Context) {
+							runScenarioWithResult(ctx, set.scenario, set.params, scnResult)
+						})
+					}
 				})
 			}
 		})
+		fileResult.Duration = time.Since(fileStart)
+		fileResult.Status = StatusPassed
+		if !ok {
+			fileResult.Status = StatusFailed
+			result.Status = StatusFailed
+		}
+	}
+}
+
+// parallel runs the scenario in parallel with other scenarios if allowed.
+// It returns the function to release the semaphore.
+func (r *Runner) parallel(ctx *context.Context, scn *schema.Scenario, sem chan struct{}) func() {
+	if r.maxParallel == 1 || !scn.IsParallel() {
+		return func() {}
+	}
+	ctx.Reporter().Parallel()
+	if sem == nil {
+		return func() {}
+	}
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+func newScenarioResult(scn *schema.Scenario, params map[string]interface{}) *ScenarioResult {
+	return &ScenarioResult{
+		Title:      scn.Title,
+		Parameters: params,
+		Steps:      []*StepResult{},
+	}
+}
+
+// runScenarioWithResult runs scn with the variables of params and records the status, duration and logs to result.
+// The logs are masked by the masker of ctx.
+func runScenarioWithResult(ctx *context.Context, scn *schema.Scenario, params map[string]interface{}, result *ScenarioResult) {
+	start := time.Now()
+	ctx = ctx.WithReporter(newLogRecorder(ctx, &result.Logs))
+	defer func() {
+		result.Status = statusOf(ctx.Reporter())
+		result.Duration = time.Since(start)
+	}()
+	if params != nil {
+		vars, err := ctx.ExecuteTemplate(params)
+		if err != nil {
+			ctx.Reporter().Fatal(scn.WrapError(errors.Wrap(err, "invalid parameters"), "parameters"))
+		}
+		ctx = ctx.WithVars(vars)
+	}
+	_ = runScenario(ctx, scn, result)
+}
+
+// parameterSet represents a set of parameters to run a scenario.
+type parameterSet struct {
+	name     string
+	params   map[string]interface{}
+	scenario *schema.Scenario // has only the steps matched the run pattern
+}
+
+// parameterSets returns the sets of parameters of scn which are matched the run pattern.
+// The run pattern is matched against the "file/scenario/parameters/step" path.
+func (r *Runner) parameterSets(file string, scn *schema.Scenario) []*parameterSet {
+	paramSets := scn.ParameterSets()
+	names := parameterSetNames(paramSets)
+	path := fmt.Sprintf("%s/%s", reporter.SubtestName(file), reporter.SubtestName(scn.Title))
+	sets := make([]*parameterSet, 0, len(paramSets))
+	for i, params := range paramSets {
+		set := &parameterSet{name: names[i], params: params, scenario: scn}
+		if r.runPattern != nil && !r.runPattern.MatchString(path) {
+			set.scenario = r.filterSteps(fmt.Sprintf("%s/%s", path, reporter.SubtestName(set.name)), scn)
+			if set.scenario == nil {
+				continue
+			}
+		}
+		sets = append(sets, set)
+	}
+	return sets
+}
+
+// parameterSetNames returns the subtest names of the sets of parameters such as "locale=en,plan=free".
+// The names of the sets which have the same parameters are suffixed with the index such as "locale=en#2" to make them unique.
+func parameterSetNames(sets []map[string]interface{}) []string {
+	names := make([]string, len(sets))
+	count := map[string]int{}
+	for i, params := range sets {
+		names[i] = parameterSetName(params)
+		count[names[i]]++
+	}
+	for i, name := range names {
+		if count[name] > 1 {
+			names[i] = fmt.Sprintf("%s#%d", name, i)
+		}
+	}
+	return names
+}
+
+// parameterSetName returns the name of the parameters such as "locale=en,plan=free".
+func parameterSetName(params map[string]interface{}) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = fmt.Sprintf("%s=%v", k, params[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// filterScenarios returns the scenarios which should be run.
+func (r *Runner) filterScenarios(file string, scns []*schema.Scenario) []*schema.Scenario {
+	filtered := make([]*schema.Scenario, 0, len(scns))
+	for _, scn := range scns {
+		if r.tagExpr != nil && !r.tagExpr.Match(scn.Tags) {
+			continue
+		}
+		if r.runPattern != nil {
+			if scn.ParameterSets() != nil {
+				// the steps are filtered for each set of parameters
+				if len(r.parameterSets(file, scn)) == 0 {
+					continue
+				}
+			} else {
+				scn = r.filterSteps(fmt.Sprintf("%s/%s", reporter.SubtestName(file), reporter.SubtestName(scn.Title)), scn)
+				if scn == nil {
+					continue
+				}
+			}
+		}
+		filtered = append(filtered, scn)
+	}
+	return filtered
+}
+
+// filterSteps returns a copy of scn which has only the steps matched the run pattern.
+// The path is the subtest name of scn such as "file/scenario".
+// It returns scn as it is if the path matches, and nil if no steps are matched.
+func (r *Runner) filterSteps(path string, scn *schema.Scenario) *schema.Scenario {
+	if r.runPattern.MatchString(path) {
+		return scn
+	}
+	steps := make([]*schema.Step, 0, len(scn.Steps))
+	for _, step := range scn.Steps {
+		if r.runPattern.MatchString(fmt.Sprintf("%s/%s", path, reporter.SubtestName(step.Title))) {
+			steps = append(steps, step)
+		}
+	}
+	if len(steps) == 0 {
+		return nil
 	}
+	filtered := *scn
+	filtered.Steps = steps
+	return &filtered
 }
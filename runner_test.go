@@ -10,13 +10,20 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/zoncoen/scenarigo/assert"
 	"github.com/zoncoen/scenarigo/context"
 	"github.com/zoncoen/scenarigo/protocol"
 	"github.com/zoncoen/scenarigo/reporter"
+	"github.com/zoncoen/scenarigo/schema"
 	"github.com/zoncoen/scenarigo/testdata/gen/pb/test"
+	"github.com/zoncoen/yaml"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -59,6 +66,34 @@ func (f builder) Build(ctx *context.Context) (assert.Assertion, error) {
 	return f(ctx)
 }
 
+// requestRecorder records the requests which are executed by templates.
+type requestRecorder struct {
+	m        sync.Mutex
+	requests []interface{}
+}
+
+func (r *requestRecorder) protocol(name string) *testProtocol {
+	return &testProtocol{
+		name: name,
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req interface{}
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				r.m.Lock()
+				r.requests = append(r.requests, v)
+				r.m.Unlock()
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+}
+
 type testGRPCServer struct {
 	users map[string]string
 }
@@ -347,3 +382,1592 @@ func TestRunner_Run_Scenarios(t *testing.T) {
 		})
 	}
 }
+
+func TestRunner_Run_WithConfig(t *testing.T) {
+	tests := map[string]struct {
+		opts   []func(*Runner) error
+		expect string
+	}{
+		"default": {
+			opts:   []func(*Runner) error{WithConfigFile("testdata/config/scenarigo.yaml")},
+			expect: "hello",
+		},
+		"profile": {
+			opts: []func(*Runner) error{
+				WithProfile("staging"),
+				WithConfigFile("testdata/config/scenarigo.yaml"),
+			},
+			expect: "hello staging",
+		},
+		"vars": {
+			opts: []func(*Runner) error{
+				WithConfigFile("testdata/config/scenarigo.yaml"),
+				WithVars(map[string]interface{}{"message": "hello vars"}),
+			},
+			expect: "hello vars",
+		},
+		"vars files": {
+			opts: []func(*Runner) error{
+				WithVarsFiles("testdata/vars/message.json", "testdata/vars/message.env"),
+				WithConfigFile("testdata/config/scenarigo.yaml"),
+			},
+			expect: "hello env",
+		},
+		"vars files and vars": {
+			opts: []func(*Runner) error{
+				WithConfigFile("testdata/config/scenarigo.yaml"),
+				WithVarsFiles("testdata/vars/message.env"),
+				WithVars(map[string]interface{}{"message": "hello vars"}),
+				WithVarsFiles("testdata/vars/message.json"),
+			},
+			expect: "hello json",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			rec := &requestRecorder{}
+			p := rec.protocol("test")
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(test.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var b bytes.Buffer
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if !ok {
+				t.Fatalf("scenario failed:\n%s", b.String())
+			}
+			if diff := cmp.Diff([]interface{}{test.expect}, rec.requests); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewRunner_WithProfile(t *testing.T) {
+	tests := map[string]struct {
+		opts []func(*Runner) error
+	}{
+		"no config": {
+			opts: []func(*Runner) error{WithProfile("staging")},
+		},
+		"profile not found": {
+			opts: []func(*Runner) error{
+				WithConfigFile("testdata/config/scenarigo.yaml"),
+				WithProfile("production"),
+			},
+		},
+		"vars file not found": {
+			opts: []func(*Runner) error{
+				WithVarsFiles("testdata/vars/notfound.yaml"),
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRunner(test.opts...); err == nil {
+				t.Fatal("expected error but no error")
+			}
+		})
+	}
+}
+
+func TestRunner_Run_WithTags(t *testing.T) {
+	tests := map[string]struct {
+		expr   string
+		expect []interface{}
+	}{
+		"tag": {
+			expr:   "smoke",
+			expect: []interface{}{"smoke", "smoke and slow"},
+		},
+		"exclude": {
+			expr:   "smoke && !slow",
+			expect: []interface{}{"smoke"},
+		},
+		"not": {
+			expr:   "!smoke",
+			expect: []interface{}{"no tags"},
+		},
+		"no match": {
+			expr: "fast",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			rec := &requestRecorder{}
+			p := rec.protocol("test")
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(WithScenarios("testdata/scenarios/tags.yaml"), WithTags(test.expr))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var b bytes.Buffer
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if !ok {
+				t.Fatalf("scenario failed:\n%s", b.String())
+			}
+			if diff := cmp.Diff(test.expect, rec.requests, cmpopts.SortSlices(func(x, y interface{}) bool {
+				return x.(string) < y.(string)
+			})); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+	t.Run("invalid expression", func(t *testing.T) {
+		if _, err := NewRunner(WithTags("smoke &&")); err == nil {
+			t.Fatal("expected error but no error")
+		}
+	})
+}
+
+func TestRunner_Run_WithRunPattern(t *testing.T) {
+	tests := map[string]struct {
+		pattern string
+		expect  []interface{}
+	}{
+		"file": {
+			pattern: "run-pattern.yaml",
+			expect:  []interface{}{"create user/GET /users", "create user/POST /users", "delete user/DELETE /users"},
+		},
+		"scenario": {
+			pattern: "/create_user$",
+			expect:  []interface{}{"create user/GET /users", "create user/POST /users"},
+		},
+		"step": {
+			pattern: "create_user/GET",
+			expect:  []interface{}{"create user/GET /users"},
+		},
+		"steps of all scenarios": {
+			pattern: "/(GET|DELETE)_/users$",
+			expect:  []interface{}{"create user/GET /users", "delete user/DELETE /users"},
+		},
+		"no match": {
+			pattern: "update_user",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			rec := &requestRecorder{}
+			p := rec.protocol("test")
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(WithScenarios("testdata/scenarios/run-pattern.yaml"), WithRunPattern(test.pattern))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var b bytes.Buffer
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if !ok {
+				t.Fatalf("scenario failed:\n%s", b.String())
+			}
+			if diff := cmp.Diff(test.expect, rec.requests, cmpopts.SortSlices(func(x, y interface{}) bool {
+				return x.(string) < y.(string)
+			})); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+	t.Run("invalid pattern", func(t *testing.T) {
+		if _, err := NewRunner(WithRunPattern("(")); err == nil {
+			t.Fatal("expected error but no error")
+		}
+	})
+}
+
+func TestNewRunner_WithScenarios(t *testing.T) {
+	tests := map[string]struct {
+		opts   []func(*Runner) error
+		expect []string
+	}{
+		"directory": {
+			opts: []func(*Runner) error{WithScenarios("testdata/discovery")},
+			expect: []string{
+				"testdata/discovery/a.yaml",
+				"testdata/discovery/b.yml",
+				"testdata/discovery/sub/c.yaml",
+				"testdata/discovery/sub/nested/keep.yaml",
+			},
+		},
+		"file": {
+			opts:   []func(*Runner) error{WithScenarios("testdata/discovery/README.md", "testdata/discovery/skip.yaml")},
+			expect: []string{"testdata/discovery/README.md", "testdata/discovery/skip.yaml"},
+		},
+		"glob": {
+			opts: []func(*Runner) error{WithScenarios("testdata/discovery/**/*.yaml")},
+			expect: []string{
+				"testdata/discovery/a.yaml",
+				"testdata/discovery/sub/c.yaml",
+				"testdata/discovery/sub/nested/keep.yaml",
+			},
+		},
+		"glob matches directories": {
+			opts:   []func(*Runner) error{WithScenarios("testdata/discovery/su?")},
+			expect: []string{"testdata/discovery/sub/c.yaml", "testdata/discovery/sub/nested/keep.yaml"},
+		},
+		"extensions": {
+			opts: []func(*Runner) error{
+				WithScenarios("testdata/discovery"),
+				WithScenarioExtensions(".json"),
+			},
+			expect: []string{"testdata/discovery/fixtures/payload.json"},
+		},
+		"duplicated": {
+			opts:   []func(*Runner) error{WithScenarios("testdata/discovery/a.yaml", "testdata/discovery/*.yaml")},
+			expect: []string{"testdata/discovery/a.yaml"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRunner(test.opts...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if diff := cmp.Diff(test.expect, r.scenarioFiles); diff != "" {
+				t.Errorf("differs (-want +got):\n%s", diff)
+			}
+		})
+	}
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			path   string
+			expect string
+		}{
+			"not found": {
+				path:   "testdata/discovery/notfound.yaml",
+				expect: "stat testdata/discovery/notfound.yaml: no such file or directory",
+			},
+			"no matches": {
+				path:   "testdata/discovery/*.json",
+				expect: `no files match the pattern "testdata/discovery/*.json"`,
+			},
+			"invalid pattern": {
+				path:   "testdata/discovery/[.yaml",
+				expect: `invalid pattern "testdata/discovery/[.yaml": syntax error in pattern`,
+			},
+			"invalid pattern in ignore file": {
+				path:   "testdata/invalid-ignore",
+				expect: "testdata/invalid-ignore/.scenarigoignore:1: invalid pattern: syntax error in pattern",
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				_, err := NewRunner(WithScenarios(test.path))
+				if err == nil {
+					t.Fatal("expected error but no error")
+				}
+				if got := err.Error(); got != test.expect {
+					t.Errorf("expect %q but got %q", test.expect, got)
+				}
+			})
+		}
+	})
+}
+
+func TestRunner_Run_MaxParallel(t *testing.T) {
+	tests := map[string]struct {
+		scenario            string
+		maxParallel         int
+		reporterMaxParallel int
+		expect              int
+	}{
+		"limited by runner": {
+			scenario:            "testdata/scenarios/parallel.yaml",
+			maxParallel:         2,
+			reporterMaxParallel: 4,
+			expect:              2,
+		},
+		"raise the limit of reporter": {
+			scenario:    "testdata/scenarios/parallel.yaml",
+			maxParallel: 4,
+			expect:      4,
+		},
+		"serial runner": {
+			scenario:            "testdata/scenarios/parallel.yaml",
+			maxParallel:         1,
+			reporterMaxParallel: 4,
+			expect:              1,
+		},
+		"limited by reporter": {
+			scenario:            "testdata/scenarios/parallel.yaml",
+			reporterMaxParallel: 4,
+			expect:              4,
+		},
+		"serial scenarios": {
+			scenario:            "testdata/scenarios/serial.yaml",
+			reporterMaxParallel: 4,
+			expect:              1,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var m sync.Mutex
+			var running, max int
+			p := &testProtocol{
+				name: "test",
+				invoker: invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+					m.Lock()
+					running++
+					if running > max {
+						max = running
+					}
+					m.Unlock()
+					time.Sleep(50 * time.Millisecond)
+					m.Lock()
+					running--
+					m.Unlock()
+					return ctx, nil, nil
+				}),
+			}
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(WithScenarios(test.scenario), WithMaxParallel(test.maxParallel))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			var b bytes.Buffer
+			opts := []reporter.Option{reporter.WithWriter(&b)}
+			if test.reporterMaxParallel != 0 {
+				opts = append(opts, reporter.WithMaxParallel(test.reporterMaxParallel))
+			}
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				r.Run(context.New(rptr))
+			}, opts...)
+			if !ok {
+				t.Fatalf("scenario failed:\n%s", b.String())
+			}
+			if max != test.expect {
+				t.Errorf("expected max parallel %d but got %d", test.expect, max)
+			}
+		})
+	}
+	t.Run("negative", func(t *testing.T) {
+		if _, err := NewRunner(WithMaxParallel(-1)); err == nil {
+			t.Fatal("expected error but no error")
+		}
+	})
+}
+
+func TestRunner_Run_Result(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				ctx = ctx.WithRequest(map[string]string{"message": req})
+				if req == "ng" {
+					return ctx, nil, errors.New("some error occurred")
+				}
+				return ctx.WithResponse(map[string]string{"message": req}), nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/result.yaml"), WithMaxParallel(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	expect := &Result{
+		Status: StatusFailed,
+		Files: []*FileResult{
+			{
+				Name:   "testdata/scenarios/result.yaml",
+				Status: StatusFailed,
+				Scenarios: []*ScenarioResult{
+					{
+						Title:  "pass",
+						Status: StatusPassed,
+						Steps: []*StepResult{
+							{
+								Title:    "step 1",
+								Status:   StatusPassed,
+								Request:  map[string]string{"message": "ok"},
+								Response: map[string]string{"message": "ok"},
+							},
+						},
+					},
+					{
+						Title:  "fail",
+						Status: StatusFailed,
+						Steps: []*StepResult{
+							{
+								Title:   "step 1",
+								Status:  StatusFailed,
+								Logs:    []string{"testdata/scenarios/result.yaml:11:12: some error occurred", "request:\nmessage: ng\n"},
+								Request: map[string]string{"message": "ng"},
+							},
+							{
+								Title:  "step 2",
+								Status: StatusSkipped,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, result, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+	if !result.Failed() {
+		t.Error("result should be failed")
+	}
+
+	b, err := json.Marshal(result.Files[0].Scenarios[0].Steps[0])
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	if got, expect := string(b), `{"title":"step 1","status":"passed","duration":`; !strings.HasPrefix(got, expect) {
+		t.Errorf("expected prefix %q but got %q", expect, got)
+	}
+	if got, expect := string(b), `"request":{"message":"ok"},"response":{"message":"ok"}}`; !strings.HasSuffix(got, expect) {
+		t.Errorf("expected suffix %q but got %q", expect, got)
+	}
+}
+
+func TestRunner_Run_Timeout(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				if req == "hang" {
+					<-ctx.RequestContext().Done()
+					return ctx, nil, ctx.RequestContext().Err()
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/timeout.yaml"), WithTimeout(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	expect := []*ScenarioResult{
+		{
+			Title:  "step timeout",
+			Status: StatusFailed,
+			Steps: []*StepResult{
+				{
+					Title:  "hang",
+					Status: StatusFailed,
+					Logs:   []string{"testdata/scenarios/timeout.yaml:6:12: step timed out after 10ms: context deadline exceeded"},
+				},
+			},
+		},
+		{
+			Title:  "scenario timeout",
+			Status: StatusFailed,
+			Steps: []*StepResult{
+				{
+					Title:  "hang",
+					Status: StatusFailed,
+					Logs:   []string{"testdata/scenarios/timeout.yaml:14:12: scenario timed out after 10ms: context deadline exceeded"},
+				},
+			},
+		},
+		{
+			Title:  "default timeout",
+			Status: StatusFailed,
+			Steps: []*StepResult{
+				{
+					Title:  "hang",
+					Status: StatusFailed,
+					Logs:   []string{"testdata/scenarios/timeout.yaml:20:12: step timed out after 10ms: context deadline exceeded"},
+				},
+				{
+					Title:  "skipped",
+					Status: StatusSkipped,
+				},
+			},
+		},
+		{
+			Title:  "pass",
+			Status: StatusPassed,
+			Steps: []*StepResult{
+				{
+					Title:  "ok",
+					Status: StatusPassed,
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, result.Files[0].Scenarios, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestWithTimeout(t *testing.T) {
+	if _, err := NewRunner(WithTimeout(-time.Second)); err == nil {
+		t.Fatal("expected error but no error")
+	}
+}
+
+func TestRunner_Run_Retry(t *testing.T) {
+	var (
+		m        sync.Mutex
+		attempts = map[string]int{}
+	)
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				m.Lock()
+				defer m.Unlock()
+				attempts[req]++
+				if req == "eventually" && attempts[req] >= 3 {
+					return ctx, nil, nil
+				}
+				return ctx, nil, errors.New("not ready")
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/retry.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	expect := []*ScenarioResult{
+		{
+			Title:  "eventually pass",
+			Status: StatusPassed,
+			Steps: []*StepResult{
+				{
+					Title:  "poll",
+					Status: StatusPassed,
+				},
+			},
+		},
+		{
+			Title:  "give up",
+			Status: StatusFailed,
+			Steps: []*StepResult{
+				{
+					Title:  "poll",
+					Status: StatusFailed,
+					Logs:   []string{"gave up after 2 attempts", "testdata/scenarios/retry.yaml:14:12: not ready"},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, result.Files[0].Scenarios, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"eventually": 3, "never": 2}, attempts); diff != "" {
+		t.Errorf("attempts differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_Teardown(t *testing.T) {
+	var requests []interface{}
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req interface{}
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				requests = append(requests, v)
+				if v == "ng" {
+					return ctx, nil, errors.New("some error occurred")
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/teardown.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	if diff := cmp.Diff([]interface{}{"create", "ng", "ng", "delete 1"}, requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+	expect := &ScenarioResult{
+		Title:  "teardown",
+		Status: StatusFailed,
+		Steps: []*StepResult{
+			{Title: "create", Status: StatusPassed},
+			{Title: "fail", Status: StatusFailed, Logs: []string{"testdata/scenarios/teardown.yaml:11:12: some error occurred"}},
+			{Title: "skipped", Status: StatusSkipped},
+		},
+		Teardown: []*StepResult{
+			{Title: "fail to delete", Status: StatusFailed, Logs: []string{"testdata/scenarios/teardown.yaml:18:12: some error occurred"}},
+			{Title: "delete", Status: StatusPassed},
+		},
+	}
+	if diff := cmp.Diff(expect, result.Files[0].Scenarios[0], cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_Include(t *testing.T) {
+	rec := &requestRecorder{}
+	p := rec.protocol("test")
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/include.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if !ok {
+		t.Fatalf("failed:\n%s", b.String())
+	}
+	if diff := cmp.Diff([]interface{}{"login admin", "logout session-admin", "alice"}, rec.requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_IncludeIsolated(t *testing.T) {
+	rec := &requestRecorder{}
+	p := rec.protocol("test")
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/include-isolated.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if ok {
+		t.Fatalf("expected failure but passed:\n%s", b.String())
+	}
+	if diff := cmp.Diff([]interface{}{"admin"}, rec.requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+	step := result.Files[0].Scenarios[0].Steps[0]
+	if diff := cmp.Diff(&StepResult{
+		Title:  "caller vars are not visible",
+		Status: StatusFailed,
+		Include: &ScenarioResult{
+			Title:  "caller vars",
+			Status: StatusFailed,
+			Steps: []*StepResult{
+				{Title: "argument", Status: StatusPassed},
+				{
+					Title:  "caller var",
+					Status: StatusFailed,
+					Logs:   []string{`testdata/scenarios/include/caller-vars.yaml:8:12: failed to execute template: ".vars.callerOnly" not found`},
+				},
+			},
+		},
+	}, step, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_IncludeRequest(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req interface{}
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				return ctx.WithRequest(v).WithResponse(fmt.Sprintf("response of %s", v)), nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	tests := map[string]struct {
+		path     string
+		ok       bool
+		requests []interface{}
+		dump     string
+	}{
+		"success": {
+			path:     "testdata/scenarios/include.yaml",
+			ok:       true,
+			requests: []interface{}{"login admin", "logout session-admin"},
+		},
+		"failure": {
+			path:     "testdata/scenarios/include-isolated.yaml",
+			requests: []interface{}{"admin"},
+			dump:     "response of admin",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRunner(WithScenarios(test.path))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var b bytes.Buffer
+			var result *Result
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				result = r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if ok != test.ok {
+				t.Fatalf("expected ok %t but got %t:\n%s", test.ok, ok, b.String())
+			}
+			for i, req := range test.requests {
+				step := result.Files[0].Scenarios[0].Steps[i]
+				if diff := cmp.Diff(req, step.Request); diff != "" {
+					t.Errorf("request of step %d differs (-want +got):\n%s", i, diff)
+				}
+				if diff := cmp.Diff(fmt.Sprintf("response of %s", req), step.Response); diff != "" {
+					t.Errorf("response of step %d differs (-want +got):\n%s", i, diff)
+				}
+			}
+			if !strings.Contains(b.String(), test.dump) {
+				t.Errorf("output does not contain %q:\n%s", test.dump, b.String())
+			}
+		})
+	}
+}
+
+func TestRunner_Run_SetupTeardown(t *testing.T) {
+	tests := map[string]struct {
+		setup    []*schema.Hook
+		ok       bool
+		requests []interface{}
+	}{
+		"success": {
+			setup: []*schema.Hook{
+				{Scenario: "testdata/hooks/setup.yaml"},
+				{Plugin: "hooks.so", Func: "Setup"},
+			},
+			ok:       true,
+			requests: []interface{}{"create", "t1/secret", "delete t1"},
+		},
+		"setup failed": {
+			setup: []*schema.Hook{
+				{Scenario: "testdata/hooks/setup-fail.yaml"},
+				{Scenario: "testdata/hooks/setup.yaml"},
+			},
+			requests: []interface{}{"ng", "delete {{vars.tenant}}"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var requests []interface{}
+			p := &testProtocol{
+				name: "test",
+				requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+					var req string
+					if err := f(&req); err != nil {
+						return nil, err
+					}
+					return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+						v, err := ctx.ExecuteTemplate(req)
+						if err != nil {
+							// record the raw request to check the variables are not defined
+							v = req
+						}
+						requests = append(requests, v)
+						if v == "ng" {
+							return ctx, nil, errors.New("some error occurred")
+						}
+						return ctx, nil, nil
+					}), nil
+				},
+			}
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(
+				WithScenarios("testdata/hooks/scenario.yaml"),
+				WithPluginDir("testdata/gen/plugins"),
+				WithSetup(test.setup...),
+				WithTeardown(&schema.Hook{Scenario: "testdata/hooks/teardown.yaml"}),
+			)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var result *Result
+			var b bytes.Buffer
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				result = r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if ok != test.ok {
+				t.Fatalf("expect %t but got %t:\n%s", test.ok, ok, b.String())
+			}
+			if got := !result.Failed(); got != test.ok {
+				t.Errorf("expect %t but got %t", test.ok, got)
+			}
+			if diff := cmp.Diff(test.requests, requests); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestWithSetup(t *testing.T) {
+	tests := map[string]struct {
+		hook *schema.Hook
+	}{
+		"empty": {
+			hook: &schema.Hook{},
+		},
+		"both scenario and plugin": {
+			hook: &schema.Hook{Scenario: "setup.yaml", Plugin: "setup.so", Func: "Setup"},
+		},
+		"no func": {
+			hook: &schema.Hook{Plugin: "setup.so"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRunner(WithSetup(test.hook)); err == nil {
+				t.Fatal("expected error but no error")
+			}
+		})
+	}
+}
+
+func TestRunner_Run_Parameters(t *testing.T) {
+	rec := &requestRecorder{}
+	p := rec.protocol("test")
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/parameters.yaml"), WithMaxParallel(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if !ok {
+		t.Fatalf("scenario failed:\n%s", b.String())
+	}
+
+	expect := []interface{}{
+		"en/free", "en/pro", "ja/free", "ja/pro",
+		"GET /admin", "GET /guest",
+	}
+	if diff := cmp.Diff(expect, rec.requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+	var params []map[string]interface{}
+	for _, scn := range result.Files[0].Scenarios {
+		params = append(params, scn.Parameters)
+	}
+	expectParams := []map[string]interface{}{
+		{"locale": "en", "plan": "free"},
+		{"locale": "en", "plan": "pro"},
+		{"locale": "ja", "plan": "free"},
+		{"locale": "ja", "plan": "pro"},
+		{"role": "admin", "status": 200, "method": "GET"},
+		{"role": "guest", "status": 403, "method": "GET"},
+	}
+	if diff := cmp.Diff(expectParams, params); diff != "" {
+		t.Errorf("parameters differ (-want +got):\n%s", diff)
+	}
+	if out := b.String(); !strings.Contains(out, "matrix/locale=ja,plan=pro/GET_/plans") {
+		t.Errorf("subtest name not found:\n%s", out)
+	}
+}
+
+func TestRunner_Run_ParametersWithRunPattern(t *testing.T) {
+	tests := map[string]struct {
+		path    string
+		pattern string
+		expect  []interface{}
+		names   []string
+	}{
+		"scenario": {
+			path:    "testdata/scenarios/parameters.yaml",
+			pattern: "/matrix$",
+			expect:  []interface{}{"en/free", "en/pro", "ja/free", "ja/pro"},
+		},
+		"parameters": {
+			path:    "testdata/scenarios/parameters.yaml",
+			pattern: "matrix/locale=ja",
+			expect:  []interface{}{"ja/free", "ja/pro"},
+		},
+		"parameters and step": {
+			path:    "testdata/scenarios/parameters.yaml",
+			pattern: "matrix/locale=en,plan=pro/GET",
+			expect:  []interface{}{"en/pro"},
+		},
+		"no match": {
+			path:    "testdata/scenarios/parameters.yaml",
+			pattern: "matrix/locale=fr",
+		},
+		"duplicated parameters": {
+			path:    "testdata/scenarios/parameters-duplicated.yaml",
+			pattern: "duplicated",
+			expect:  []interface{}{"a", "a", "b"},
+			names:   []string{"duplicated/name=a#0/request", "duplicated/name=a#1/request", "duplicated/name=b/request"},
+		},
+		"one of duplicated parameters": {
+			path:    "testdata/scenarios/parameters-duplicated.yaml",
+			pattern: "name=a#1",
+			expect:  []interface{}{"a"},
+			names:   []string{"duplicated/name=a#1/request"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			rec := &requestRecorder{}
+			p := rec.protocol("test")
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(WithScenarios(test.path), WithRunPattern(test.pattern), WithMaxParallel(1))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var b bytes.Buffer
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if !ok {
+				t.Fatalf("scenario failed:\n%s", b.String())
+			}
+			if diff := cmp.Diff(test.expect, rec.requests); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+			for _, name := range test.names {
+				if out := b.String(); !strings.Contains(out, "--- PASS: "+test.path+"/"+name+" ") {
+					t.Errorf("subtest %s not found:\n%s", name, out)
+				}
+			}
+		})
+	}
+}
+
+func TestRunner_Run_Loop(t *testing.T) {
+	tests := map[string]struct {
+		path     string
+		ok       bool
+		requests []interface{}
+		logs     []string
+	}{
+		"success": {
+			path: "testdata/scenarios/loop.yaml",
+			ok:   true,
+			requests: []interface{}{
+				map[interface{}]interface{}{"page": 0},
+				map[interface{}]interface{}{"page": 1},
+				map[interface{}]interface{}{"index": 0, "item": "alice"},
+				map[interface{}]interface{}{"index": 1, "item": "bob"},
+				map[interface{}]interface{}{"last": "bob"},
+			},
+		},
+		"failure": {
+			path:     "testdata/scenarios/loop-fail.yaml",
+			requests: []interface{}{"ok", "ng"},
+			logs:     []string{"testdata/scenarios/loop-fail.yaml:5:12: some error occurred", "failed at loop index 1"},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			var requests []interface{}
+			p := &testProtocol{
+				name: "test",
+				requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+					var req interface{}
+					if err := f(&req); err != nil {
+						return nil, err
+					}
+					return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+						v, err := ctx.ExecuteTemplate(req)
+						if err != nil {
+							return ctx, nil, err
+						}
+						requests = append(requests, v)
+						if v == "ng" {
+							return ctx, nil, errors.New("some error occurred")
+						}
+						return ctx, nil, nil
+					}), nil
+				},
+			}
+			protocol.Register(p)
+			defer protocol.Unregister(p.Name())
+
+			r, err := NewRunner(WithScenarios(test.path))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			var b bytes.Buffer
+			var result *Result
+			ok := reporter.Run(func(rptr reporter.Reporter) {
+				result = r.Run(context.New(rptr))
+			}, reporter.WithWriter(&b))
+			if ok != test.ok {
+				t.Fatalf("expect %t but got %t:\n%s", test.ok, ok, b.String())
+			}
+			if diff := cmp.Diff(test.requests, requests); diff != "" {
+				t.Errorf("requests differ (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(test.logs, result.Files[0].Scenarios[0].Steps[0].Logs); diff != "" {
+				t.Errorf("logs differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRunner_Run_If(t *testing.T) {
+	rec := &requestRecorder{}
+	p := rec.protocol("test")
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/if.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if !ok {
+		t.Fatalf("scenario failed:\n%s", b.String())
+	}
+
+	if diff := cmp.Diff([]interface{}{"enabled", "always"}, rec.requests); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+	expect := []*StepResult{
+		{Title: "enabled", Status: StatusPassed},
+		{Title: "disabled", Status: StatusSkipped, Logs: []string{`skipped because the condition is false: {{vars.flag}}`}},
+		{Title: "always", Status: StatusPassed},
+	}
+	if diff := cmp.Diff(expect, result.Files[0].Scenarios[0].Steps, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_Wait(t *testing.T) {
+	r, err := NewRunner(WithScenarios("testdata/scenarios/wait.yaml"), WithMaxParallel(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var count int
+	vars := map[string]interface{}{
+		"ready": func() bool {
+			count++
+			return count == 3
+		},
+		"never": false,
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr).WithVars(vars))
+	}, reporter.WithWriter(&b))
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	if count != 3 {
+		t.Errorf("expect the condition is evaluated 3 times but got %d", count)
+	}
+	scns := result.Files[0].Scenarios
+	if got := scns[0].Status; got != StatusPassed {
+		t.Errorf("expect %s but got %s:\n%s", StatusPassed, got, b.String())
+	}
+	if got := scns[0].Steps[0].Duration; got < 10*time.Millisecond {
+		t.Errorf("expect to wait 10ms but got %s", got)
+	}
+	step := scns[1].Steps[0]
+	if step.Status != StatusFailed {
+		t.Errorf("expect %s but got %s", StatusFailed, step.Status)
+	}
+	// the number of attempts depends on the timing
+	if expect := "testdata/scenarios/wait.yaml:15:16: waitFor timed out after 50ms: condition {{vars.never}} is not satisfied after "; len(step.Logs) != 1 || !strings.HasPrefix(step.Logs[0], expect) {
+		t.Errorf("expect log %q but got %q", expect, step.Logs)
+	}
+}
+
+func TestRunner_Run_ErrorPosition(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		invoker: invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+			return ctx, map[string]interface{}{"message": "hi", "count": 1}, nil
+		}),
+		expectUnmarshaller: func(f func(interface{}) error) (protocol.AssertionBuilder, error) {
+			var expect struct {
+				Body yaml.KeyOrderPreservedInterface `yaml:"body"`
+			}
+			if err := f(&expect); err != nil {
+				return nil, err
+			}
+			return builder(func(ctx *context.Context) (assert.Assertion, error) {
+				return protocol.CreateAssertion(expect.Body), nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/error-position.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	expect := []*StepResult{
+		{
+			Title:  "assert",
+			Status: StatusFailed,
+			Logs:   []string{"testdata/scenarios/error-position.yaml:9:16: .message: expected hello but got hi"},
+		},
+		{
+			Title:  "invalid vars",
+			Status: StatusFailed,
+			Logs:   []string{`testdata/scenarios/error-position.yaml:13:3: invalid vars: failed to execute template: ".vars.undefined" not found`},
+		},
+	}
+	if diff := cmp.Diff(expect, result.Files[0].Scenarios[0].Steps, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_ContinueOnError(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				if req == "ng" {
+					return ctx, nil, errors.New("some error occurred")
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/continue-on-error.yaml"), WithMaxParallel(1))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	})
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	var got [][]Status
+	for _, scn := range result.Files[0].Scenarios {
+		if scn.Status != StatusFailed {
+			t.Errorf("%s: expect %s but got %s", scn.Title, StatusFailed, scn.Status)
+		}
+		var statuses []Status
+		for _, step := range scn.Steps {
+			statuses = append(statuses, step.Status)
+		}
+		got = append(got, statuses)
+	}
+	expect := [][]Status{
+		{StatusFailed, StatusPassed, StatusFailed, StatusSkipped},
+		{StatusFailed, StatusFailed, StatusPassed},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("statuses differ (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_ParallelSteps(t *testing.T) {
+	var (
+		m        sync.Mutex
+		requests []interface{}
+		running  int
+	)
+	started := make(chan struct{})
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req interface{}
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				m.Lock()
+				requests = append(requests, v)
+				m.Unlock()
+				if v == "create" || v == "alice" {
+					return ctx, nil, nil
+				}
+				m.Lock()
+				running++
+				if running == 3 {
+					close(started)
+				}
+				m.Unlock()
+				// wait until all parallel steps start
+				select {
+				case <-started:
+				case <-time.After(time.Second):
+					return ctx, nil, errors.New("parallel steps are not run simultaneously")
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/parallel-steps.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if !ok {
+		t.Fatalf("scenario failed:\n%s", b.String())
+	}
+
+	expect := []interface{}{"create", "orders 1", "user 1", "wait", "alice"}
+	if diff := cmp.Diff(expect, requests, cmpopts.SortSlices(func(a, b interface{}) bool {
+		return fmt.Sprint(a) < fmt.Sprint(b)
+	})); diff != "" {
+		t.Errorf("requests differ (-want +got):\n%s", diff)
+	}
+	if got := requests[len(requests)-1]; got != "alice" {
+		t.Errorf("the step after parallel steps must be run at last but got %v", got)
+	}
+	step := result.Files[0].Scenarios[0].Steps[1]
+	if diff := cmp.Diff(&StepResult{
+		Title:  "read",
+		Status: StatusPassed,
+		Steps: []*StepResult{
+			{Title: "user", Status: StatusPassed},
+			{Title: "orders", Status: StatusPassed},
+			{Title: "wait", Status: StatusPassed},
+		},
+	}, step, cmpopts.IgnoreTypes(time.Duration(0)), cmpopts.IgnoreUnexported(StepResult{})); diff != "" {
+		t.Errorf("result differs (-want +got):\n%s", diff)
+	}
+}
+
+func TestRunner_Run_Secrets(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req yaml.MapSlice
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				ctx = ctx.WithRequest(v)
+				password, err := ctx.ExecuteTemplate("{{secrets.password}}")
+				if err != nil {
+					return ctx, nil, err
+				}
+				return ctx, nil, fmt.Errorf("invalid password %s", password)
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	if err := os.Setenv("TEST_SECRET_TOKEN", "t0ken"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer os.Unsetenv("TEST_SECRET_TOKEN")
+
+	r, err := NewRunner(
+		WithScenarios("testdata/scenarios/secrets.yaml"),
+		WithSecrets(map[string]interface{}{
+			"password": "p@ssw0rd",
+			"token":    "{{env.TEST_SECRET_TOKEN}}",
+		}),
+		WithMaskedFields("APIKEY"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+
+	step := result.Files[0].Scenarios[0].Steps[0]
+	expectRequest := yaml.MapSlice{
+		{Key: "user", Value: "alice"},
+		{Key: "password", Value: context.MaskedValue},
+		{Key: "authorization", Value: context.MaskedValue},
+		{Key: "apiKey", Value: context.MaskedValue},
+		{Key: "note", Value: "token is " + context.MaskedValue},
+	}
+	if diff := cmp.Diff(expectRequest, step.MaskedRequest()); diff != "" {
+		t.Errorf("request differs (-want +got):\n%s", diff)
+	}
+	j, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal: %s", err)
+	}
+	for name, out := range map[string]string{
+		"output": b.String(),
+		"logs":   strings.Join(step.Logs, "\n"),
+		"json":   string(j),
+	} {
+		for _, secret := range []string{"p@ssw0rd", "t0ken", "plain-key"} {
+			if strings.Contains(out, secret) {
+				t.Errorf("%s contains the secret %q:\n%s", name, secret, out)
+			}
+		}
+		if !strings.Contains(out, context.MaskedValue) {
+			t.Errorf("%s has no masked values:\n%s", name, out)
+		}
+	}
+}
+
+func TestRunner_Run_DefaultMaskedFields(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req yaml.MapSlice
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				return ctx.WithRequest(req), nil, errors.New("unauthorized")
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/authorization.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+	if strings.Contains(b.String(), "t0ken") {
+		t.Errorf("output contains the authorization header:\n%s", b.String())
+	}
+	if !strings.Contains(b.String(), context.MaskedValue) {
+		t.Errorf("output has no masked values:\n%s", b.String())
+	}
+}
+
+func TestRunner_Run_SecretParameters(t *testing.T) {
+	p := &testProtocol{name: "test"}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(
+		WithScenarios("testdata/scenarios/secrets-parameters.yaml"),
+		WithSecrets(map[string]interface{}{"password": "p@ssw0rd"}),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	var result *Result
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		result = r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if ok {
+		t.Fatal("expected failure but passed")
+	}
+	scn := result.Files[0].Scenarios[0]
+	if got, expect := scn.Status, StatusFailed; got != expect {
+		t.Errorf("expected status %s but got %s", expect, got)
+	}
+	for name, out := range map[string]string{
+		"output": b.String(),
+		"logs":   strings.Join(scn.Logs, "\n"),
+	} {
+		if strings.Contains(out, "p@ssw0rd") {
+			t.Errorf("%s contains the secret:\n%s", name, out)
+		}
+		if !strings.Contains(out, "invalid parameters") {
+			t.Errorf("%s has no error of the parameters:\n%s", name, out)
+		}
+	}
+}
+
+func TestNewRunner_WithSecrets(t *testing.T) {
+	tests := map[string]struct {
+		opts []func(*Runner) error
+	}{
+		"undefined env": {
+			opts: []func(*Runner) error{
+				WithSecrets(map[string]interface{}{"token": "{{env.TEST_UNDEFINED_SECRET}}"}),
+			},
+		},
+		"secrets file not found": {
+			opts: []func(*Runner) error{
+				WithSecretsFiles("testdata/vars/notfound.env"),
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if _, err := NewRunner(test.opts...); err == nil {
+				t.Fatal("expected error but no error")
+			}
+		})
+	}
+}
+
+func TestRunner_Run_ParallelStepsVars(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req string
+			if err := f(&req); err != nil {
+				return nil, err
+			}
+			return invoker(func(ctx *context.Context) (*context.Context, interface{}, error) {
+				v, err := ctx.ExecuteTemplate(req)
+				if err != nil {
+					return ctx, nil, err
+				}
+				// the step vars of the sibling steps must not be visible
+				if s := strings.Fields(v.(string)); s[0] != s[1] {
+					return ctx, nil, fmt.Errorf("expected vars.name %q but got %q", s[0], s[1])
+				}
+				return ctx, nil, nil
+			}), nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	r, err := NewRunner(WithScenarios("testdata/scenarios/parallel-steps-vars.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	var b bytes.Buffer
+	ok := reporter.Run(func(rptr reporter.Reporter) {
+		r.Run(context.New(rptr))
+	}, reporter.WithWriter(&b))
+	if !ok {
+		t.Fatalf("scenario failed:\n%s", b.String())
+	}
+}
@@ -1,14 +1,25 @@
 package scenarigo
 
 import (
+	gocontext "context"
 	"path/filepath"
 	"plugin"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	"github.com/zoncoen/scenarigo/context"
 	"github.com/zoncoen/scenarigo/schema"
 )
 
-func runScenario(ctx *context.Context, s *schema.Scenario) *context.Context {
+func runScenario(ctx *context.Context, s *schema.Scenario, result *ScenarioResult) *context.Context {
+	reqCtx := ctx.RequestContext()
+	if s.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = withTimeout(ctx, "scenario", s.Timeout)
+		defer cancel()
+	}
+
 	if s.Plugins != nil {
 		plugs := map[string]*plugin.Plugin{}
 		for name, path := range s.Plugins {
@@ -18,7 +29,7 @@ func runScenario(ctx *context.Context, s *schema.Scenario) *context.Context {
 			}
 			plug, err := plugin.Open(path)
 			if err != nil {
-				ctx.Reporter().Fatalf("failed to open plugin: %s", err)
+				ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "failed to open plugin"), "plugins", name))
 			}
 			plugs[name] = plug
 		}
@@ -28,49 +39,151 @@ func runScenario(ctx *context.Context, s *schema.Scenario) *context.Context {
 	if s.Vars != nil {
 		vars, err := ctx.ExecuteTemplate(s.Vars)
 		if err != nil {
-			ctx.Reporter().Fatalf("invalid vars: %s", err)
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "invalid vars"), "vars"))
 		}
 		ctx = ctx.WithVars(vars)
 	}
 
+	scnCtx := runSteps(ctx, s, s.Steps, &result.Steps, false)
+	if len(s.Teardown) > 0 {
+		// teardown steps are run with a fresh request context even if the scenario timed out
+		scnCtx = runSteps(scnCtx.WithRequestContext(reqCtx), s, s.Teardown, &result.Teardown, true)
+	}
+
+	// the returned context must not be bound to the canceled request context
+	return scnCtx.WithRequestContext(reqCtx)
+}
+
+// bindVars returns the values of step to bind to the scenario context.
+func bindVars(ctx *context.Context, step *schema.Step) interface{} {
+	if step.Bind.Vars == nil {
+		return nil
+	}
+	vars, err := ctx.ExecuteTemplate(step.Bind.Vars)
+	if err != nil {
+		ctx.Reporter().Fatal(step.WrapError(errors.Wrap(err, "invalid bind"), "bind"))
+	}
+	return vars
+}
+
+// runSteps runs steps in order and returns the context which has the bound variables.
+// The following steps are skipped if a step failed unless always is true or the step continues on error.
+func runSteps(ctx *context.Context, s *schema.Scenario, steps []*schema.Step, results *[]*StepResult, always bool) *context.Context {
 	scnCtx := ctx
 	var failed bool
-	for _, step := range s.Steps {
+	for _, step := range steps {
 		step := step
+		stepResult := &StepResult{
+			Title: step.Title,
+		}
+		*results = append(*results, stepResult)
 		ok := scnCtx.Run(step.Title, func(ctx *context.Context) {
-			// print debug information if the step failed
-			var debug func()
-			defer func() {
-				if ctx.Reporter().Failed() {
-					if debug != nil {
-						debug()
-					}
-				}
-			}()
-
 			// following steps are skipped if the previous step failed
-			if failed {
+			if failed && !always {
+				stepResult.Status = StatusSkipped
 				ctx.Reporter().SkipNow()
 			}
-
-			if step.Include != "" {
-				step.Include = filepath.Join(filepath.Dir(s.Filepath()), step.Include)
-			}
-			ctx = runStep(ctx, step, &debug)
-
-			// bind values to the scenario context for enable to access from following steps
-			if step.Bind.Vars != nil {
-				vars, err := ctx.ExecuteTemplate(step.Bind.Vars)
-				if err != nil {
-					ctx.Reporter().Fatalf("invalid bind: %s", err)
-				}
+			execStep(ctx, s, step, stepResult, func(vars interface{}) {
+				// bind values to the scenario context for enable to access from following steps
 				scnCtx = scnCtx.WithVars(vars)
-			}
+			})
 		})
-		if !failed {
-			failed = !ok
+		if !ok && !step.ContinueOnError && !s.ContinueOnError {
+			failed = true
 		}
 	}
-
 	return scnCtx
 }
+
+// execStep runs step as a subtest and records the result.
+// The values to bind are passed to bind in order.
+func execStep(ctx *context.Context, s *schema.Scenario, step *schema.Step, result *StepResult, bind func(interface{})) {
+	start := time.Now()
+	ctx = ctx.WithReporter(newLogRecorder(ctx, &result.Logs))
+	defer func() {
+		// print debug information if the step failed
+		if ctx.Reporter().Failed() {
+			dumpReqResp(ctx, result)
+		}
+		result.Status = statusOf(ctx.Reporter())
+		result.Duration = time.Since(start)
+	}()
+
+	run, err := shouldRun(ctx, step)
+	if err != nil {
+		ctx.Reporter().Fatal(step.WrapError(errors.Wrap(err, "invalid if"), "if"))
+	}
+	if !run {
+		ctx.Reporter().Skipf("skipped because the condition is false: %s", step.If)
+	}
+
+	if step.Parallel != nil {
+		runParallelSteps(ctx, s, step, result, bind)
+		return
+	}
+
+	if step.Include != nil {
+		// copy not to modify the scenario which may be run repeatedly
+		stp, inc := *step, *step.Include
+		inc.Path = filepath.Join(filepath.Dir(s.Filepath()), inc.Path)
+		stp.Include = &inc
+		step = &stp
+	}
+
+	loop, err := loopVars(ctx, step)
+	if err != nil {
+		ctx.Reporter().Fatal(step.WrapError(errors.Wrap(err, "invalid loop"), "foreach"))
+	}
+	if loop == nil {
+		bind(bindVars(runStep(ctx, step, result), step))
+		return
+	}
+	for _, vars := range loop {
+		vars := vars
+		func() {
+			defer func() {
+				if ctx.Reporter().Failed() {
+					ctx.Reporter().Logf("failed at loop index %d", vars["index"])
+				}
+			}()
+			// the values bound in the previous iterations are accessible
+			bound := bindVars(runStep(ctx.WithVars(map[string]interface{}{"loop": vars}), step, result), step)
+			bind(bound)
+			ctx = ctx.WithVars(bound)
+		}()
+	}
+}
+
+// runParallelSteps runs the child steps of step concurrently.
+// The values bound by the child steps are passed to bind in the order of the steps after all child steps finished.
+func runParallelSteps(ctx *context.Context, s *schema.Scenario, step *schema.Step, result *StepResult, bind func(interface{})) {
+	if step.Timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = withTimeout(ctx, "step", step.Timeout)
+		defer cancel()
+	}
+	bound := make([][]interface{}, len(step.Parallel))
+	result.Steps = make([]*StepResult, len(step.Parallel))
+	var wg sync.WaitGroup
+	for i, child := range step.Parallel {
+		i, child := i, child
+		result.Steps[i] = &StepResult{
+			Title: child.Title,
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx.Run(child.Title, func(ctx *context.Context) {
+				execStep(ctx, s, child, result.Steps[i], func(vars interface{}) {
+					bound[i] = append(bound[i], vars)
+				})
+			})
+		}()
+	}
+	wg.Wait()
+	for _, vars := range bound {
+		for _, v := range vars {
+			bind(v)
+		}
+	}
+}
@@ -125,7 +125,7 @@ func TestRunScenario_PrintDebugInfo(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			var b bytes.Buffer
 			reporter.Run(func(rptr reporter.Reporter) {
-				runScenario(context.New(rptr), test.scenario)
+				runScenario(context.New(rptr), test.scenario, &ScenarioResult{})
 			}, reporter.WithWriter(&b))
 			if diff := cmp.Diff(test.expect, "\n"+b.String()); diff != "" {
 				t.Errorf("differs (-want +got):\n%s", diff)
@@ -166,7 +166,7 @@ func TestRunScenario_FailFast(t *testing.T) {
 		},
 	}
 	reporter.Run(func(rptr reporter.Reporter) {
-		runScenario(context.New(rptr), scenario)
+		runScenario(context.New(rptr), scenario, &ScenarioResult{})
 	})
 	if called {
 		t.Fatal("following steps should be skipped if the previous step failed")
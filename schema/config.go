@@ -0,0 +1,250 @@
+package schema
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/yaml"
+)
+
+// DefaultConfigFileName is the default file name of the project configuration.
+const DefaultConfigFileName = "scenarigo.yaml"
+
+// output formats
+const (
+	// OutputFormatText prints test results like "go test -v".
+	OutputFormatText = "text"
+	// OutputFormatJSON prints the structured test result as JSON.
+	OutputFormatJSON = "json"
+)
+
+// Config represents a project configuration.
+type Config struct {
+	Scenarios       []string               `yaml:"scenarios"`
+	PluginDirectory string                 `yaml:"pluginDirectory"`
+	Vars            map[string]interface{} `yaml:"vars"`
+	VarsFiles       []string               `yaml:"varsFiles"`
+	Secrets         map[string]interface{} `yaml:"secrets"`
+	SecretsFiles    []string               `yaml:"secretsFiles"`
+	MaskedFields    []string               `yaml:"maskedFields"`
+	MaxParallel     int                    `yaml:"maxParallel"`
+	Timeout         time.Duration          `yaml:"timeout"`
+	Setup           []*Hook                `yaml:"setup"`
+	Teardown        []*Hook                `yaml:"teardown"`
+	Output          OutputConfig           `yaml:"output"`
+	Profiles        map[string]*Profile    `yaml:"profiles"`
+
+	root string // directory of the configuration file
+}
+
+// OutputConfig represents an output configuration.
+type OutputConfig struct {
+	Format string `yaml:"format"`
+}
+
+// Hook represents a setup or teardown hook of the test suite.
+// Either a scenario file or a function of a plugin must be specified.
+type Hook struct {
+	// Scenario is the path of the scenario file.
+	Scenario string `yaml:"scenario"`
+	// Plugin is the path of the plugin relative to the plugin directory.
+	Plugin string `yaml:"plugin"`
+	// Func is the name of the plugin function such as "func(*plugin.Context) *plugin.Context".
+	Func string `yaml:"func"`
+}
+
+// Name returns the name of h to report.
+func (h *Hook) Name() string {
+	if h.Scenario != "" {
+		return h.Scenario
+	}
+	return fmt.Sprintf("%s.%s", h.Plugin, h.Func)
+}
+
+// Validate validates h.
+func (h *Hook) Validate() error {
+	switch {
+	case h.Scenario != "" && h.Plugin != "":
+		return errors.New("either scenario or plugin must be specified")
+	case h.Scenario != "":
+		if h.Func != "" {
+			return errors.New("func can be used with only plugin")
+		}
+	case h.Plugin != "":
+		if h.Func == "" {
+			return errors.New("func of plugin must be specified")
+		}
+	default:
+		return errors.New("scenario or plugin must be specified")
+	}
+	return nil
+}
+
+// Profile represents an environment profile which overrides the configuration.
+type Profile struct {
+	PluginDirectory string                 `yaml:"pluginDirectory"`
+	Vars            map[string]interface{} `yaml:"vars"`
+	VarsFiles       []string               `yaml:"varsFiles"`
+	Secrets         map[string]interface{} `yaml:"secrets"`
+	SecretsFiles    []string               `yaml:"secretsFiles"`
+	MaxParallel     int                    `yaml:"maxParallel"`
+}
+
+// LoadConfig loads the project configuration from path.
+// Relative paths in the configuration are resolved from the directory of path.
+// The variables of the vars files are loaded into Vars, and the vars in the configuration file override them.
+// The secrets files are loaded into Secrets in the same way.
+func LoadConfig(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var c Config
+	d := yaml.NewDecoder(f)
+	d.SetStrict(true)
+	if err := d.Decode(&c); err != nil {
+		return nil, errors.Wrap(err, "failed to decode YAML")
+	}
+	c.root = filepath.Dir(path)
+
+	for i, p := range c.Scenarios {
+		c.Scenarios[i] = c.resolvePath(p)
+	}
+	c.PluginDirectory = c.resolvePath(c.PluginDirectory)
+	for i, p := range c.VarsFiles {
+		c.VarsFiles[i] = c.resolvePath(p)
+	}
+	for i, p := range c.SecretsFiles {
+		c.SecretsFiles[i] = c.resolvePath(p)
+	}
+	for _, h := range append(c.Setup, c.Teardown...) {
+		if h != nil {
+			h.Scenario = c.resolvePath(h.Scenario)
+		}
+	}
+	for name, p := range c.Profiles {
+		if p == nil {
+			return nil, errors.Errorf(`profile "%s" is empty`, name)
+		}
+		p.PluginDirectory = c.resolvePath(p.PluginDirectory)
+		for i, f := range p.VarsFiles {
+			p.VarsFiles[i] = c.resolvePath(f)
+		}
+		for i, f := range p.SecretsFiles {
+			p.SecretsFiles[i] = c.resolvePath(f)
+		}
+	}
+
+	if err := c.validate(); err != nil {
+		return nil, errors.Wrapf(err, `invalid config "%s"`, path)
+	}
+	if len(c.VarsFiles) > 0 {
+		vars, err := LoadVarsFiles(c.VarsFiles...)
+		if err != nil {
+			return nil, err
+		}
+		c.Vars = MergeVars(vars, c.Vars)
+	}
+	if len(c.SecretsFiles) > 0 {
+		secrets, err := LoadVarsFiles(c.SecretsFiles...)
+		if err != nil {
+			return nil, err
+		}
+		c.Secrets = MergeVars(secrets, c.Secrets)
+	}
+	return &c, nil
+}
+
+func (c *Config) resolvePath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.root, path)
+}
+
+func (c *Config) validate() error {
+	switch c.Output.Format {
+	case "", OutputFormatText, OutputFormatJSON:
+	default:
+		return errors.Errorf(`unknown output format "%s"`, c.Output.Format)
+	}
+	if c.MaxParallel < 0 {
+		return errors.Errorf("maxParallel must not be negative but got %d", c.MaxParallel)
+	}
+	if c.Timeout < 0 {
+		return errors.Errorf("timeout must not be negative but got %s", c.Timeout)
+	}
+	for i, h := range c.Setup {
+		if err := validateHook(h); err != nil {
+			return errors.Wrapf(err, "invalid setup[%d]", i)
+		}
+	}
+	for i, h := range c.Teardown {
+		if err := validateHook(h); err != nil {
+			return errors.Wrapf(err, "invalid teardown[%d]", i)
+		}
+	}
+	for name, p := range c.Profiles {
+		if p.MaxParallel < 0 {
+			return errors.Errorf(`profile "%s": maxParallel must not be negative but got %d`, name, p.MaxParallel)
+		}
+	}
+	return nil
+}
+
+func validateHook(h *Hook) error {
+	if h == nil {
+		return errors.New("empty hook")
+	}
+	return h.Validate()
+}
+
+// Root returns the directory of the configuration file.
+func (c *Config) Root() string {
+	return c.root
+}
+
+// WithProfile returns a copy of c overridden by the profile called name.
+// The variables are overridden in the order of the vars files and the vars of the profile, and so are the secrets.
+// The vars and secrets files of the profile are loaded only when it is selected.
+func (c *Config) WithProfile(name string) (*Config, error) {
+	p, ok := c.Profiles[name]
+	if !ok {
+		return nil, errors.Errorf(`profile "%s" not found`, name)
+	}
+	newConfig := *c
+	if p.PluginDirectory != "" {
+		newConfig.PluginDirectory = p.PluginDirectory
+	}
+	if p.MaxParallel != 0 {
+		newConfig.MaxParallel = p.MaxParallel
+	}
+	if len(p.VarsFiles) > 0 {
+		vars, err := LoadVarsFiles(p.VarsFiles...)
+		if err != nil {
+			return nil, errors.Wrapf(err, `profile "%s"`, name)
+		}
+		newConfig.Vars = MergeVars(newConfig.Vars, vars)
+		newConfig.VarsFiles = append(append([]string{}, c.VarsFiles...), p.VarsFiles...)
+	}
+	if p.Vars != nil {
+		newConfig.Vars = MergeVars(newConfig.Vars, p.Vars)
+	}
+	if len(p.SecretsFiles) > 0 {
+		secrets, err := LoadVarsFiles(p.SecretsFiles...)
+		if err != nil {
+			return nil, errors.Wrapf(err, `profile "%s"`, name)
+		}
+		newConfig.Secrets = MergeVars(newConfig.Secrets, secrets)
+		newConfig.SecretsFiles = append(append([]string{}, c.SecretsFiles...), p.SecretsFiles...)
+	}
+	if p.Secrets != nil {
+		newConfig.Secrets = MergeVars(newConfig.Secrets, p.Secrets)
+	}
+	return &newConfig, nil
+}
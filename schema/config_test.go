@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadConfig(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		got, err := LoadConfig("testdata/config/scenarigo.yaml")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		expect := &Config{
+			Scenarios:       []string{"testdata/config/scenarios", "/tmp/scenarios"},
+			PluginDirectory: "testdata/config/plugins",
+			Vars: map[string]interface{}{
+				"endpoint": "http://localhost:8080",
+				"user":     "alice",
+				"token":    "common-token",
+			},
+			VarsFiles: []string{"testdata/config/vars/common.yaml"},
+			Secrets: map[string]interface{}{
+				"password": "p@ssw0rd",
+				"apiKey":   "secret-key",
+			},
+			SecretsFiles: []string{"testdata/config/vars/secrets.env"},
+			MaskedFields: []string{"X-Api-Key"},
+			MaxParallel:  4,
+			Timeout:      30 * time.Second,
+			Setup: []*Hook{
+				{Scenario: "testdata/config/setup/tenants.yaml"},
+				{Plugin: "setup.so", Func: "Setup"},
+			},
+			Teardown: []*Hook{
+				{Scenario: "testdata/config/setup/cleanup.yaml"},
+			},
+			Output: OutputConfig{
+				Format: OutputFormatText,
+			},
+			Profiles: map[string]*Profile{
+				"staging": {
+					PluginDirectory: "testdata/config/staging/plugins",
+					Vars: map[string]interface{}{
+						"endpoint": "https://staging.example.com",
+					},
+					VarsFiles: []string{"testdata/config/vars/.env.staging"},
+					Secrets: map[string]interface{}{
+						"password": "staging-p@ssw0rd",
+					},
+					MaxParallel: 2,
+				},
+			},
+			root: "testdata/config",
+		}
+		if diff := cmp.Diff(expect, got, cmp.AllowUnexported(Config{})); diff != "" {
+			t.Errorf("config differs (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			path string
+		}{
+			"not found": {
+				path: "testdata/config/notfound.yaml",
+			},
+			"unknown field": {
+				path: "testdata/config/unknown-field.yaml",
+			},
+			"invalid output format": {
+				path: "testdata/config/invalid-format.yaml",
+			},
+			"invalid hook": {
+				path: "testdata/config/invalid-hook.yaml",
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				if _, err := LoadConfig(test.path); err == nil {
+					t.Fatal("expected error but no error")
+				}
+			})
+		}
+	})
+}
+
+func TestConfig_WithProfile(t *testing.T) {
+	c, err := LoadConfig("testdata/config/scenarigo.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	t.Run("found", func(t *testing.T) {
+		got, err := c.WithProfile("staging")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got, expect := got.PluginDirectory, "testdata/config/staging/plugins"; got != expect {
+			t.Errorf("expected plugin directory %q but got %q", expect, got)
+		}
+		if got, expect := got.MaxParallel, 2; got != expect {
+			t.Errorf("expected max parallel %d but got %d", expect, got)
+		}
+		expectVars := map[string]interface{}{
+			"endpoint": "https://staging.example.com",
+			"user":     "alice",
+			"token":    "staging-token",
+		}
+		if diff := cmp.Diff(expectVars, got.Vars); diff != "" {
+			t.Errorf("vars differs (-want +got):\n%s", diff)
+		}
+		expectSecrets := map[string]interface{}{
+			"password": "staging-p@ssw0rd",
+			"apiKey":   "secret-key",
+		}
+		if diff := cmp.Diff(expectSecrets, got.Secrets); diff != "" {
+			t.Errorf("secrets differs (-want +got):\n%s", diff)
+		}
+		if got, expect := c.Vars["endpoint"], "http://localhost:8080"; got != expect {
+			t.Errorf("original config must not be modified: expected %q but got %q", expect, got)
+		}
+		if diff := cmp.Diff([]string{"testdata/config/vars/common.yaml"}, c.VarsFiles); diff != "" {
+			t.Errorf("original config must not be modified (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("not found", func(t *testing.T) {
+		if _, err := c.WithProfile("production"); err == nil {
+			t.Fatal("expected error but no error")
+		}
+	})
+}
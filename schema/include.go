@@ -0,0 +1,72 @@
+package schema
+
+import (
+	"github.com/pkg/errors"
+)
+
+// Include represents a scenario which is included as a step.
+//
+// It can be specified as a path string or a mapping which has the arguments and return values.
+//
+//	include: login.yaml
+//
+//	include:
+//	  path: auth.yaml
+//	  scenario: login
+//	  vars:
+//	    user: "{{vars.admin}}"
+//	  bind:
+//	    token: "{{vars.token}}"
+type Include struct {
+	// Path is the path of the scenario file. A relative path is resolved from the directory of the including scenario file.
+	Path string `yaml:"path"`
+	// Scenario is the title of the scenario to include. It can be omitted if the file has only one scenario.
+	Scenario string `yaml:"scenario"`
+	// Vars are the arguments which are passed to the included scenario.
+	Vars map[string]interface{} `yaml:"vars"`
+	// Bind are the return values which are evaluated at the end of the included scenario.
+	Bind map[string]interface{} `yaml:"bind"`
+
+	shared bool // specified as a path string
+}
+
+type includeUnmarshaller Include
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (i *Include) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var path string
+	if err := unmarshal(&path); err == nil {
+		*i = Include{Path: path, shared: true}
+		return nil
+	}
+	if err := unmarshal((*includeUnmarshaller)(i)); err != nil {
+		return err
+	}
+	if i.Path == "" {
+		return errors.New("include must have path")
+	}
+	return nil
+}
+
+// IsShared reports whether the included scenario shares the variables with the caller.
+// A scenario included by a path string exposes all variables which it defines to the caller,
+// while the other one exposes only the values of Bind.
+func (i *Include) IsShared() bool {
+	return i.shared
+}
+
+// Select returns the scenario to include from the scenarios loaded from Path.
+func (i *Include) Select(scns []*Scenario) (*Scenario, error) {
+	if i.Scenario == "" {
+		if len(scns) != 1 {
+			return nil, errors.New("must be a scenario")
+		}
+		return scns[0], nil
+	}
+	for _, scn := range scns {
+		if scn.Title == i.Scenario {
+			return scn, nil
+		}
+	}
+	return nil, errors.Errorf(`scenario "%s" not found`, i.Scenario)
+}
@@ -0,0 +1,89 @@
+package schema
+
+import (
+	"reflect"
+
+	"github.com/zoncoen/scenarigo/jsonschema"
+	"github.com/zoncoen/scenarigo/protocol"
+)
+
+// JSONSchema returns the JSON Schema of scenario files.
+// The request and expect of steps are described by the registered protocols which implement protocol.SchemaProvider.
+func JSONSchema() *jsonschema.Schema {
+	r := &jsonschema.Reflector{
+		Definitions: map[string]*jsonschema.Schema{},
+	}
+	s := r.Reflect(reflect.TypeOf(Scenario{}))
+	s.Schema = jsonschema.Draft07
+	s.Title = "scenarigo scenario"
+	s.Definitions = r.Definitions
+
+	step := r.Definitions["Step"]
+	for _, p := range protocol.List() {
+		sp, ok := p.(protocol.SchemaProvider)
+		if !ok {
+			continue
+		}
+		step.AllOf = append(step.AllOf, &jsonschema.Schema{
+			If: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{
+					"protocol": {Const: p.Name()},
+				},
+				Required: []string{"protocol"},
+			},
+			Then: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{
+					"request": sp.RequestSchema(),
+					"expect":  sp.ExpectSchema(),
+				},
+			},
+		})
+	}
+	return s
+}
+
+// JSONSchema implements jsonschema.Provider interface.
+func (p Parameters) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{
+				Type: "array",
+				Items: &jsonschema.Schema{
+					Type: "object",
+				},
+			},
+			{
+				Type: "object",
+				Properties: map[string]*jsonschema.Schema{
+					"file": {Type: "string"},
+				},
+				Required:             []string{"file"},
+				AdditionalProperties: false,
+			},
+		},
+	}
+}
+
+// JSONSchema implements jsonschema.Provider interface.
+func (i Include) JSONSchema() *jsonschema.Schema {
+	s := (&jsonschema.Reflector{}).Reflect(reflect.TypeOf(includeUnmarshaller{}))
+	s.Required = []string{"path"}
+	return &jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "string"},
+			s,
+		},
+	}
+}
+
+// JSONSchema implements jsonschema.Provider interface.
+// The schema of the request depends on the protocol.
+func (r Request) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{}
+}
+
+// JSONSchema implements jsonschema.Provider interface.
+// The schema of the expect depends on the protocol.
+func (e Expect) JSONSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{}
+}
@@ -0,0 +1,76 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zoncoen/scenarigo/jsonschema"
+	"github.com/zoncoen/scenarigo/protocol"
+)
+
+type testSchemaProtocol struct {
+	testProtocol
+}
+
+func (p *testSchemaProtocol) RequestSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "object"}
+}
+
+func (p *testSchemaProtocol) ExpectSchema() *jsonschema.Schema {
+	return &jsonschema.Schema{Type: "string"}
+}
+
+func TestJSONSchema(t *testing.T) {
+	withSchema := &testSchemaProtocol{testProtocol{name: "with-schema"}}
+	protocol.Register(withSchema)
+	defer protocol.Unregister(withSchema.Name())
+	withoutSchema := &testProtocol{name: "without-schema"}
+	protocol.Register(withoutSchema)
+	defer protocol.Unregister(withoutSchema.Name())
+
+	s := JSONSchema()
+	if got, expect := s.Schema, jsonschema.Draft07; got != expect {
+		t.Errorf("expected %q but got %q", expect, got)
+	}
+	if diff := cmp.Diff(&jsonschema.Schema{
+		Type:  "array",
+		Items: &jsonschema.Schema{Ref: "#/definitions/Step"},
+	}, s.Properties["steps"]); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+
+	step, ok := s.Definitions["Step"]
+	if !ok {
+		t.Fatal("Step is not defined")
+	}
+	if _, ok := step.Properties["parallel"]; !ok {
+		t.Error("Step has no parallel property")
+	}
+	var conds []*jsonschema.Schema
+	for _, c := range step.AllOf {
+		switch c.If.Properties["protocol"].Const {
+		case withSchema.Name():
+			conds = append(conds, c)
+		case withoutSchema.Name():
+			t.Errorf("%s does not provide the schema", withoutSchema.Name())
+		}
+	}
+	if diff := cmp.Diff([]*jsonschema.Schema{
+		{
+			If: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{
+					"protocol": {Const: withSchema.Name()},
+				},
+				Required: []string{"protocol"},
+			},
+			Then: &jsonschema.Schema{
+				Properties: map[string]*jsonschema.Schema{
+					"request": {Type: "object"},
+					"expect":  {Type: "string"},
+				},
+			},
+		},
+	}, conds); diff != "" {
+		t.Errorf("differs: (-want +got)\n%s", diff)
+	}
+}
@@ -1,34 +1,99 @@
 package schema
 
 import (
+	"bytes"
 	"io"
-	"os"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
 
 	"github.com/pkg/errors"
 	"github.com/zoncoen/yaml"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 // LoadScenarios loads test scenarios from path.
+// The errors have the positions in the file as *Error.
 func LoadScenarios(path string) ([]*Scenario, error) {
-	f, err := os.Open(path)
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	src := newSource(path, b)
+	// the positions are not available if failed to parse because the decoder reports the syntax error
+	nodes, _ := parseNodes(b)
+	positions := func(i int) *nodePositions {
+		p := &nodePositions{src: src}
+		if i < len(nodes) {
+			p.node = nodes[i]
+		}
+		return p
+	}
 
 	var scenarios []*Scenario
-	d := yaml.NewDecoder(f)
+	d := yaml.NewDecoder(bytes.NewReader(b))
 	d.SetStrict(true)
-	for {
+	for i := 0; ; i++ {
 		var s Scenario
 		if err := d.Decode(&s); err != nil {
 			if err == io.EOF {
 				break
 			}
-			return nil, errors.Wrap(err, "failed to decode YAML")
+			if e, ok := err.(*stepError); ok {
+				keys, cause := e.path(&s)
+				return nil, positions(i).loadError(cause, keys...)
+			}
+			// the errors of the decoder have the line numbers in the messages
+			return nil, &Error{Pos: Position{Filename: path}, Err: errors.Wrap(err, "failed to decode YAML")}
 		}
 		s.filepath = path
+		s.positions = positions(i)
+		setStepPositions(s.positions, s.Steps, "steps")
+		setStepPositions(s.positions, s.Teardown, "teardown")
+		if err := s.Parameters.load(filepath.Dir(path)); err != nil {
+			return nil, s.positions.loadError(errors.Wrapf(err, `invalid scenario "%s"`, s.Title), "parameters")
+		}
+		if err := s.validateParameters(); err != nil {
+			return nil, s.positions.loadError(errors.Wrapf(err, `invalid scenario "%s"`, s.Title), "parameters")
+		}
 		scenarios = append(scenarios, &s)
 	}
 	return scenarios, nil
 }
+
+// setStepPositions sets the positions of steps and their parallel steps with the nodes in the sequence at the path of keys from p.
+func setStepPositions(p *nodePositions, steps []*Step, keys ...string) {
+	seq, _ := p.lookup(keys...)
+	seq = resolveAlias(seq)
+	for i, step := range steps {
+		if step == nil {
+			continue
+		}
+		step.positions = &nodePositions{src: p.src}
+		if seq != nil && seq.Kind == yaml3.SequenceNode && i < len(seq.Content) {
+			step.positions.node = seq.Content[i]
+		}
+		setStepPositions(step.positions, step.Parallel, "parallel")
+	}
+}
+
+// path returns the path of keys from s to the step which causes e and the error of the step.
+func (e *stepError) path(s *Scenario) ([]string, error) {
+	var keys []string
+	switch e.steps {
+	case &s.Steps:
+		keys = append(keys, "steps")
+	case &s.Teardown:
+		keys = append(keys, "teardown")
+	}
+	keys = append(keys, strconv.Itoa(e.index))
+	err := e.err
+	for {
+		se, ok := err.(*stepError)
+		if !ok {
+			return keys, err
+		}
+		keys = append(keys, "parallel", strconv.Itoa(se.index))
+		err = se.err
+	}
+}
@@ -2,8 +2,10 @@ package schema
 
 import (
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/zoncoen/scenarigo/protocol"
 )
 
@@ -61,6 +63,87 @@ func TestLoadScenarios(t *testing.T) {
 					"body": map[interface{}]interface{}{"message": "{{request.body}}"},
 				},
 			},
+			"retry": {
+				path: "testdata/retry.yaml",
+
+				scenarios: []*Scenario{
+					{
+						Title: "retry",
+						Steps: []*Step{
+							{
+								Title:    "GET /status",
+								Protocol: "test",
+								Retry: &RetryPolicy{
+									MaxAttempts:    5,
+									Interval:       100 * time.Millisecond,
+									MaxInterval:    time.Second,
+									Multiplier:     2,
+									MaxElapsedTime: 10 * time.Second,
+								},
+							},
+						},
+						filepath: "testdata/retry.yaml",
+					},
+				},
+				request: map[interface{}]interface{}{
+					"body": "status",
+				},
+				expect: map[interface{}]interface{}{},
+			},
+			"include": {
+				path: "testdata/include.yaml",
+
+				scenarios: []*Scenario{
+					{
+						Title: "include",
+						Steps: []*Step{
+							{
+								Title:   "path",
+								Include: &Include{Path: "login.yaml", shared: true},
+							},
+							{
+								Title: "arguments",
+								Include: &Include{
+									Path:     "auth.yaml",
+									Scenario: "login",
+									Vars:     map[string]interface{}{"user": "alice"},
+									Bind:     map[string]interface{}{"token": "{{vars.session}}"},
+								},
+							},
+						},
+						filepath: "testdata/include.yaml",
+					},
+				},
+				request: map[interface{}]interface{}{},
+				expect:  map[interface{}]interface{}{},
+			},
+			"parameters file": {
+				path: "testdata/parameters/scenario.yaml",
+
+				scenarios: []*Scenario{
+					{
+						Title: "parameters from file",
+						Parameters: Parameters{
+							Sets: []map[string]interface{}{
+								{"role": "admin", "status": "200"},
+								{"role": "guest", "status": "403"},
+							},
+							File: "users.csv",
+						},
+						Steps: []*Step{
+							{
+								Title:    "GET /",
+								Protocol: "test",
+							},
+						},
+						filepath: "testdata/parameters/scenario.yaml",
+					},
+				},
+				request: map[interface{}]interface{}{
+					"body": "{{vars.role}}",
+				},
+				expect: map[interface{}]interface{}{},
+			},
 		}
 		for name, test := range tests {
 			test := test
@@ -79,8 +162,9 @@ func TestLoadScenarios(t *testing.T) {
 				}
 				if diff := cmp.Diff(test.scenarios, got,
 					cmp.AllowUnexported(
-						Scenario{}, Request{}, Expect{},
+						Scenario{}, Step{}, Request{}, Expect{}, Include{},
 					),
+					cmpopts.IgnoreTypes(&nodePositions{}),
 					cmp.FilterPath(func(path cmp.Path) bool {
 						s := path.String()
 						if s == "Steps.Request" {
@@ -122,6 +206,33 @@ func TestLoadScenarios(t *testing.T) {
 			"unknown protocol": {
 				path: "testdata/unknown-protocol.yaml",
 			},
+			"retry without limits": {
+				path: "testdata/invalid-retry.yaml",
+			},
+			"retry with include": {
+				path: "testdata/retry-include.yaml",
+			},
+			"include without path": {
+				path: "testdata/invalid-include.yaml",
+			},
+			"wait with request": {
+				path: "testdata/invalid-wait.yaml",
+			},
+			"waitFor without timeout": {
+				path: "testdata/invalid-wait-for.yaml",
+			},
+			"empty matrix": {
+				path: "testdata/empty-matrix.yaml",
+			},
+			"parallel with request": {
+				path: "testdata/invalid-parallel.yaml",
+			},
+			"repeat and foreach": {
+				path: "testdata/invalid-loop.yaml",
+			},
+			"parameters file not found": {
+				path: "testdata/parameters/not-found.yaml",
+			},
 		}
 		for name, test := range tests {
 			test := test
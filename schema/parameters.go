@@ -0,0 +1,151 @@
+package schema
+
+import (
+	"encoding/csv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/yaml"
+)
+
+// Parameters represents the sets of parameters of a scenario.
+// It is written as a list of parameters or a fixture file such as "{file: users.csv}".
+type Parameters struct {
+	Sets []map[string]interface{}
+	// File is the path of the CSV, JSON or YAML file relative to the scenario file.
+	File string
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (p *Parameters) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var sets []map[string]interface{}
+	if err := unmarshal(&sets); err == nil {
+		p.Sets = sets
+		return nil
+	}
+	var f struct {
+		File string `yaml:"file"`
+	}
+	if err := unmarshal(&f); err != nil || f.File == "" {
+		return errors.New("parameters must be a list of parameters or {file: path}")
+	}
+	p.File = f.File
+	return nil
+}
+
+// load loads the sets of parameters from the file.
+// The path of the file is resolved from dir.
+func (p *Parameters) load(dir string) error {
+	if p.File == "" {
+		return nil
+	}
+	path := p.File
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	sets, err := loadParameterFile(path)
+	if err != nil {
+		return errors.Wrapf(err, `failed to load parameters from "%s"`, path)
+	}
+	if len(sets) == 0 {
+		return errors.Errorf(`no parameters in "%s"`, path)
+	}
+	p.Sets = sets
+	return nil
+}
+
+// loadParameterFile loads the sets of parameters from the CSV, JSON or YAML file.
+// The first record of the CSV file is the header which has the names of the parameters,
+// and all values are loaded as strings.
+func loadParameterFile(path string) ([]map[string]interface{}, error) {
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".csv":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) == 0 {
+			return nil, nil
+		}
+		header := records[0]
+		sets := make([]map[string]interface{}, 0, len(records)-1)
+		for _, record := range records[1:] {
+			params := make(map[string]interface{}, len(header))
+			for i, name := range header {
+				params[name] = record[i]
+			}
+			sets = append(sets, params)
+		}
+		return sets, nil
+	case ".json", ".yaml", ".yml":
+		// JSON is a subset of YAML
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var sets []map[string]interface{}
+		if err := yaml.Unmarshal(b, &sets); err != nil {
+			return nil, err
+		}
+		return sets, nil
+	default:
+		return nil, errors.Errorf(`unsupported file extension "%s"`, ext)
+	}
+}
+
+// ParameterSets returns the sets of parameters to run s repeatedly.
+// The matrix is expanded into the cartesian product of the values.
+// If both parameters and matrix are specified, each set of parameters is combined with each combination of the matrix.
+// It returns nil if s has neither parameters nor matrix.
+func (s *Scenario) ParameterSets() []map[string]interface{} {
+	if len(s.Parameters.Sets) == 0 && len(s.Matrix) == 0 {
+		return nil
+	}
+	sets := []map[string]interface{}{{}}
+	if len(s.Parameters.Sets) > 0 {
+		sets = s.Parameters.Sets
+	}
+	keys := make([]string, 0, len(s.Matrix))
+	for k := range s.Matrix {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		expanded := make([]map[string]interface{}, 0, len(sets)*len(s.Matrix[k]))
+		for _, set := range sets {
+			for _, v := range s.Matrix[k] {
+				params := make(map[string]interface{}, len(set)+1)
+				for name, value := range set {
+					params[name] = value
+				}
+				params[k] = v
+				expanded = append(expanded, params)
+			}
+		}
+		sets = expanded
+	}
+	return sets
+}
+
+func (s *Scenario) validateParameters() error {
+	for i, params := range s.Parameters.Sets {
+		if len(params) == 0 {
+			return errors.Errorf("parameters[%d] is empty", i)
+		}
+	}
+	for k, values := range s.Matrix {
+		if len(values) == 0 {
+			return errors.Errorf(`matrix "%s" has no values`, k)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestScenario_ParameterSets(t *testing.T) {
+	tests := map[string]struct {
+		scenario *Scenario
+		expect   []map[string]interface{}
+	}{
+		"no parameters": {
+			scenario: &Scenario{},
+		},
+		"parameters": {
+			scenario: &Scenario{
+				Parameters: Parameters{
+					Sets: []map[string]interface{}{
+						{"role": "admin"},
+						{"role": "guest"},
+					},
+				},
+			},
+			expect: []map[string]interface{}{
+				{"role": "admin"},
+				{"role": "guest"},
+			},
+		},
+		"matrix": {
+			scenario: &Scenario{
+				Matrix: map[string][]interface{}{
+					"plan":   {"free", "pro"},
+					"locale": {"en", "ja"},
+				},
+			},
+			expect: []map[string]interface{}{
+				{"locale": "en", "plan": "free"},
+				{"locale": "en", "plan": "pro"},
+				{"locale": "ja", "plan": "free"},
+				{"locale": "ja", "plan": "pro"},
+			},
+		},
+		"parameters and matrix": {
+			scenario: &Scenario{
+				Parameters: Parameters{
+					Sets: []map[string]interface{}{
+						{"role": "admin"},
+						{"role": "guest"},
+					},
+				},
+				Matrix: map[string][]interface{}{
+					"locale": {"en", "ja"},
+				},
+			},
+			expect: []map[string]interface{}{
+				{"role": "admin", "locale": "en"},
+				{"role": "admin", "locale": "ja"},
+				{"role": "guest", "locale": "en"},
+				{"role": "guest", "locale": "ja"},
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if diff := cmp.Diff(test.expect, test.scenario.ParameterSets()); diff != "" {
+				t.Errorf("parameters differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoadParameterFile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tests := map[string]struct {
+			path   string
+			expect []map[string]interface{}
+		}{
+			"csv": {
+				path: "testdata/parameters/users.csv",
+				expect: []map[string]interface{}{
+					{"role": "admin", "status": "200"},
+					{"role": "guest", "status": "403"},
+				},
+			},
+			"json": {
+				path: "testdata/parameters/users.json",
+				expect: []map[string]interface{}{
+					{"role": "admin", "status": 200},
+					{"role": "guest", "status": 403},
+				},
+			},
+			"yaml": {
+				path: "testdata/parameters/users.yaml",
+				expect: []map[string]interface{}{
+					{"role": "admin", "status": 200},
+					{"role": "guest", "status": 403},
+				},
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				got, err := loadParameterFile(test.path)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if diff := cmp.Diff(test.expect, got); diff != "" {
+					t.Errorf("parameters differ (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			path string
+		}{
+			"not found": {
+				path: "testdata/parameters/not-found.csv",
+			},
+			"wrong number of fields": {
+				path: "testdata/parameters/invalid.csv",
+			},
+			"unsupported extension": {
+				path: "testdata/parameters/users.txt",
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				if _, err := loadParameterFile(test.path); err == nil {
+					t.Fatal("expected error but no error")
+				}
+			})
+		}
+	})
+}
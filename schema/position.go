@@ -0,0 +1,243 @@
+package schema
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	yaml3 "gopkg.in/yaml.v3"
+)
+
+// snippetLines is the number of lines which are printed before and after the error position.
+const snippetLines = 2
+
+// Position represents a position in a YAML file.
+type Position struct {
+	Filename string
+	Line     int // starting at 1
+	Column   int // starting at 1
+}
+
+// IsValid reports whether p has the line number.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns p as "file.yaml:42:7".
+// The column is omitted if it is unknown.
+func (p Position) String() string {
+	if !p.IsValid() {
+		return p.Filename
+	}
+	if p.Column == 0 {
+		return fmt.Sprintf("%s:%d", p.Filename, p.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Column)
+}
+
+// Error represents an error at a position of a YAML file.
+type Error struct {
+	Pos Position
+	Err error
+	// Snippet is the part of the YAML file around the position. It may be empty.
+	Snippet string
+}
+
+// Error implements error interface.
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("%s: %s", e.Pos, e.Err)
+	if e.Snippet != "" {
+		msg = fmt.Sprintf("%s\n%s", msg, e.Snippet)
+	}
+	return msg
+}
+
+// Cause returns the underlying error.
+func (e *Error) Cause() error {
+	return e.Err
+}
+
+// source represents a YAML file to report positions.
+type source struct {
+	filename string
+	lines    []string
+}
+
+func newSource(filename string, b []byte) *source {
+	return &source{
+		filename: filename,
+		lines:    strings.Split(strings.TrimSuffix(string(b), "\n"), "\n"),
+	}
+}
+
+// position returns the position of n.
+func (s *source) position(n *yaml3.Node) Position {
+	if n == nil {
+		return Position{Filename: s.filename}
+	}
+	return Position{
+		Filename: s.filename,
+		Line:     n.Line,
+		Column:   n.Column,
+	}
+}
+
+// snippet returns the lines around pos with a marker.
+//
+//	  41 | - title: GET /users
+//	> 42 |   retry: {}
+//	     |   ^
+func (s *source) snippet(pos Position) string {
+	if !pos.IsValid() || pos.Line > len(s.lines) {
+		return ""
+	}
+	first, last := pos.Line-snippetLines, pos.Line+snippetLines
+	if first < 1 {
+		first = 1
+	}
+	if last > len(s.lines) {
+		last = len(s.lines)
+	}
+	width := len(strconv.Itoa(last))
+	var b strings.Builder
+	for i := first; i <= last; i++ {
+		mark := " "
+		if i == pos.Line {
+			mark = ">"
+		}
+		fmt.Fprintf(&b, "%s %*d | %s\n", mark, width, i, s.lines[i-1])
+		if i == pos.Line && pos.Column > 0 {
+			fmt.Fprintf(&b, "  %*s | %s^\n", width, "", strings.Repeat(" ", pos.Column-1))
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// nodePositions holds the YAML node of a scenario or step to find the positions of its fields.
+type nodePositions struct {
+	src  *source
+	node *yaml3.Node
+}
+
+// lookup returns the node at the path of keys from the node.
+// Mapping keys and sequence indexes are specified as strings.
+func (p *nodePositions) lookup(keys ...string) (*yaml3.Node, bool) {
+	n, _, ok := p.lookupWithKey(keys...)
+	return n, ok
+}
+
+// lookupWithKey is like lookup but also returns the mapping key node of the value if exists.
+func (p *nodePositions) lookupWithKey(keys ...string) (*yaml3.Node, *yaml3.Node, bool) {
+	if p == nil || p.node == nil {
+		return nil, nil, false
+	}
+	var key *yaml3.Node
+	n := p.node
+	for _, k := range keys {
+		n = resolveAlias(n)
+		var found bool
+		switch n.Kind {
+		case yaml3.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if n.Content[i].Value == k {
+					key, n, found = n.Content[i], n.Content[i+1], true
+					break
+				}
+			}
+		case yaml3.SequenceNode:
+			if i, err := strconv.Atoi(k); err == nil && i >= 0 && i < len(n.Content) {
+				key, n, found = nil, n.Content[i], true
+			}
+		}
+		if !found {
+			return nil, nil, false
+		}
+	}
+	return n, key, true
+}
+
+// position returns the position of the value at the path of keys.
+// The position of the key is returned instead if the value is a mapping or sequence which starts at the next line.
+// It returns the position of the nearest ancestor if the value is not found.
+func (p *nodePositions) position(keys ...string) Position {
+	if p == nil {
+		return Position{}
+	}
+	if p.node == nil {
+		return Position{Filename: p.src.filename}
+	}
+	for i := len(keys); i >= 0; i-- {
+		if n, key, ok := p.lookupWithKey(keys[:i]...); ok {
+			if key != nil && resolveAlias(n).Kind != yaml3.ScalarNode {
+				return p.src.position(key)
+			}
+			return p.src.position(n)
+		}
+	}
+	return p.src.position(p.node)
+}
+
+// wrap returns an *Error which has err and the position of the value at the path of keys.
+// It returns err as it is if the positions are unknown.
+func (p *nodePositions) wrap(err error, keys ...string) error {
+	if err == nil || p == nil || p.node == nil {
+		return err
+	}
+	return &Error{
+		Pos: p.position(keys...),
+		Err: err,
+	}
+}
+
+// loadError returns an *Error which has err, the position and the snippet of the value at the path of keys.
+// Only the filename is reported if the node is unknown.
+func (p *nodePositions) loadError(err error, keys ...string) error {
+	pos := p.position(keys...)
+	return &Error{Pos: pos, Err: err, Snippet: p.src.snippet(pos)}
+}
+
+func resolveAlias(n *yaml3.Node) *yaml3.Node {
+	for n != nil && n.Kind == yaml3.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+	return n
+}
+
+var queryTokenRegexp = regexp.MustCompile(`\.([^.\[]+)|\[(\d+)\]`)
+
+// queryKeys splits the query string such as ".items[0].name" into the keys.
+func queryKeys(query string) []string {
+	var keys []string
+	for _, m := range queryTokenRegexp.FindAllStringSubmatch(query, -1) {
+		if m[1] != "" {
+			keys = append(keys, m[1])
+		} else {
+			keys = append(keys, m[2])
+		}
+	}
+	return keys
+}
+
+// parseNodes parses the YAML documents in b to find the positions of the scenarios and steps.
+func parseNodes(b []byte) ([]*yaml3.Node, error) {
+	var docs []*yaml3.Node
+	d := yaml3.NewDecoder(bytes.NewReader(b))
+	for {
+		var n yaml3.Node
+		if err := d.Decode(&n); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(n.Content) > 0 {
+			docs = append(docs, n.Content[0])
+		} else {
+			docs = append(docs, &n)
+		}
+	}
+	return docs, nil
+}
@@ -0,0 +1,171 @@
+package schema
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zoncoen/scenarigo/protocol"
+)
+
+func TestLoadScenarios_ErrorPosition(t *testing.T) {
+	p := &testProtocol{name: "test"}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	tests := map[string]struct {
+		path   string
+		expect string
+	}{
+		"step": {
+			path: "testdata/invalid-wait.yaml",
+			expect: `testdata/invalid-wait.yaml:3:3: wait can not be used with request
+  1 | title: wait with request
+  2 | steps:
+> 3 | - title: wait
+    |   ^
+  4 |   wait: 1s
+  5 |   protocol: test`,
+		},
+		"nested step": {
+			path: "testdata/invalid-parallel.yaml",
+			expect: `testdata/invalid-parallel.yaml:3:5: parallel can not be used with request
+  1 | title: parallel with request
+  2 | steps:
+> 3 |   - title: GET /
+    |     ^
+  4 |     protocol: test
+  5 |     request:`,
+		},
+		"teardown step": {
+			path: "testdata/invalid-teardown.yaml",
+			expect: `testdata/invalid-teardown.yaml:8:3: wait must not be negative but got -1s
+  6 | - title: ok
+  7 |   protocol: test
+> 8 | - title: wait
+    |   ^
+  9 |   wait: -1s`,
+		},
+		"decoder error": {
+			path: "testdata/invalid.yaml",
+			expect: `testdata/invalid.yaml: failed to decode YAML: yaml: unmarshal errors:
+  line 1: cannot unmarshal !!map into string`,
+		},
+		"decoder error in step": {
+			path: "testdata/invalid-step-field.yaml",
+			expect: `testdata/invalid-step-field.yaml:5:3: yaml: unmarshal errors:
+  line 6: cannot unmarshal !!seq into schema.RetryPolicy
+  3 | - title: ok
+  4 |   protocol: test
+> 5 | - title: retry
+    |   ^
+  6 |   retry: []`,
+		},
+		"syntax error": {
+			path:   "testdata/broken.yaml",
+			expect: `testdata/broken.yaml: failed to decode YAML: yaml: line 3: did not find expected node content`,
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadScenarios(test.path)
+			if err == nil {
+				t.Fatal("expected error but no error")
+			}
+			if diff := cmp.Diff(test.expect, err.Error()); diff != "" {
+				t.Errorf("error differs (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestStep_Position(t *testing.T) {
+	p := &testProtocol{name: "test"}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	scns, err := LoadScenarios("testdata/position.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	steps := scns[0].Steps
+	tests := map[string]struct {
+		step   *Step
+		keys   []string
+		expect string
+	}{
+		"step": {
+			step:   steps[0],
+			expect: "testdata/position.yaml:3:3",
+		},
+		"field": {
+			step:   steps[0],
+			keys:   []string{"request", "url"},
+			expect: "testdata/position.yaml:6:10",
+		},
+		"index": {
+			step:   steps[0],
+			keys:   []string{"expect", "body", "items", "0", "name"},
+			expect: "testdata/position.yaml:10:15",
+		},
+		"not found": {
+			step:   steps[0],
+			keys:   []string{"request", "body"},
+			expect: "testdata/position.yaml:5:3",
+		},
+		"parallel": {
+			step:   steps[1].Parallel[0],
+			keys:   []string{"request"},
+			expect: "testdata/position.yaml:15:5",
+		},
+		"not loaded": {
+			step: &Step{},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			if got := test.step.Position(test.keys...).String(); got != test.expect {
+				t.Errorf("expect %q but got %q", test.expect, got)
+			}
+		})
+	}
+}
+
+func TestStep_WrapAssertionError(t *testing.T) {
+	p := &testProtocol{name: "test"}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	scns, err := LoadScenarios("testdata/position.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	tests := map[string]struct {
+		query  string
+		expect string
+	}{
+		"child of expect": {
+			query:  ".items[0].name",
+			expect: "testdata/position.yaml:10:15: assertion failed",
+		},
+		"expect": {
+			query:  ".body.items",
+			expect: "testdata/position.yaml:9:7: assertion failed",
+		},
+		"not found": {
+			query:  ".unknown",
+			expect: "testdata/position.yaml:7:3: assertion failed",
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			err := scns[0].Steps[0].WrapAssertionError(errors.New("assertion failed"), test.query)
+			if got := err.Error(); got != test.expect {
+				t.Errorf("expect %q but got %q", test.expect, got)
+			}
+		})
+	}
+}
@@ -2,21 +2,39 @@
 package schema
 
 import (
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/zoncoen/scenarigo/assert"
 	"github.com/zoncoen/scenarigo/context"
 	"github.com/zoncoen/scenarigo/protocol"
+	yaml3 "gopkg.in/yaml.v3"
 )
 
 // Scenario represents a test scenario.
 type Scenario struct {
-	Title       string                 `yaml:"title"`
-	Description string                 `yaml:"description"`
-	Plugins     map[string]string      `yaml:"plugins"`
-	Vars        map[string]interface{} `yaml:"vars"`
-	Steps       []*Step                `yaml:"steps"`
+	Title           string                   `yaml:"title"`
+	Description     string                   `yaml:"description"`
+	Tags            []string                 `yaml:"tags"`
+	Parallel        *bool                    `yaml:"parallel"`
+	Timeout         time.Duration            `yaml:"timeout"`
+	ContinueOnError bool                     `yaml:"continueOnError"`
+	Plugins         map[string]string        `yaml:"plugins"`
+	Parameters      Parameters               `yaml:"parameters"`
+	Matrix          map[string][]interface{} `yaml:"matrix"`
+	Vars            map[string]interface{}   `yaml:"vars"`
+	Steps           Steps                    `yaml:"steps"`
+	Teardown        Steps                    `yaml:"teardown"`
+
+	filepath  string // YAML filepath
+	positions *nodePositions
+}
 
-	filepath string // YAML filepath
+// IsParallel reports whether s can be run in parallel with other scenarios.
+// Scenarios are run in parallel unless "parallel: false" is specified.
+func (s *Scenario) IsParallel() bool {
+	return s.Parallel == nil || *s.Parallel
 }
 
 // Filepath returns YAML filepath of s.
@@ -24,17 +42,127 @@ func (s *Scenario) Filepath() string {
 	return s.filepath
 }
 
+// Position returns the position of the value at the path of keys from s such as ("vars").
+// It returns the position of the nearest ancestor if the value does not exist, and an invalid position if s is not loaded from a file.
+func (s *Scenario) Position(keys ...string) Position {
+	return s.positions.position(keys...)
+}
+
+// WrapError returns an *Error which has err and the position of the value at the path of keys from s such as ("vars").
+// It returns err as it is if s is not loaded from a file.
+func (s *Scenario) WrapError(err error, keys ...string) error {
+	return s.positions.wrap(err, keys...)
+}
+
 // Step represents a step of scenario.
 type Step struct {
-	Title       string                 `yaml:"title"`
-	Description string                 `yaml:"description"`
-	Vars        map[string]interface{} `yaml:"vars"`
-	Protocol    string                 `yaml:"protocol"`
-	Request     Request                `yaml:"request"`
-	Expect      Expect                 `yaml:"expect"`
-	Include     string                 `yaml:"include"`
-	Ref         string                 `yaml:"ref"`
-	Bind        Bind                   `yaml:"bind"`
+	Title           string                 `yaml:"title"`
+	Description     string                 `yaml:"description"`
+	If              string                 `yaml:"if"`
+	Timeout         time.Duration          `yaml:"timeout"`
+	Vars            map[string]interface{} `yaml:"vars"`
+	Protocol        string                 `yaml:"protocol"`
+	Request         Request                `yaml:"request"`
+	Expect          Expect                 `yaml:"expect"`
+	Retry           *RetryPolicy           `yaml:"retry"`
+	Repeat          int                    `yaml:"repeat"`
+	Foreach         interface{}            `yaml:"foreach"`
+	ContinueOnError bool                   `yaml:"continueOnError"`
+	Include         *Include               `yaml:"include"`
+	Ref             string                 `yaml:"ref"`
+	Wait            time.Duration          `yaml:"wait"`
+	WaitFor         *WaitFor               `yaml:"waitFor"`
+	Bind            Bind                   `yaml:"bind"`
+	Parallel        Steps                  `yaml:"parallel"`
+
+	positions *nodePositions
+}
+
+// Steps represents a sequence of steps.
+type Steps []*Step
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+// The errors of the steps are returned with their indexes to report the positions.
+func (s *Steps) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var items []stepItem
+	if err := unmarshal(&items); err != nil {
+		return err
+	}
+	steps := make(Steps, len(items))
+	for i, item := range items {
+		if item.unmarshal == nil {
+			continue
+		}
+		if err := item.unmarshal(&steps[i]); err != nil {
+			return &stepError{steps: s, index: i, err: err}
+		}
+	}
+	*s = steps
+	return nil
+}
+
+// stepItem holds the unmarshal function of a step to decode it with the index.
+type stepItem struct {
+	unmarshal func(interface{}) error
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler interface.
+func (i *stepItem) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	i.unmarshal = unmarshal
+	return nil
+}
+
+// stepError represents an error of the step at the index of steps.
+// err is also a *stepError if the error occurs in the parallel steps of the step.
+type stepError struct {
+	steps *Steps
+	index int
+	err   error
+}
+
+// Error implements error interface.
+func (e *stepError) Error() string {
+	return e.err.Error()
+}
+
+// Cause returns the underlying error.
+func (e *stepError) Cause() error {
+	return e.err
+}
+
+// Position returns the position of the value at the path of keys from s such as ("request", "body").
+// Mapping keys and sequence indexes are specified as strings.
+// It returns the position of the nearest ancestor if the value does not exist, and an invalid position if s is not loaded from a file.
+func (s *Step) Position(keys ...string) Position {
+	return s.positions.position(keys...)
+}
+
+// WrapError returns an *Error which has err and the position of the value at the path of keys.
+// It returns err as it is if s is not loaded from a file.
+func (s *Step) WrapError(err error, keys ...string) error {
+	return s.positions.wrap(err, keys...)
+}
+
+// WrapAssertionError returns an *Error which has err and the position of the expected value extracted by query such as ".items[0].name".
+// The query is resolved from the expect or its direct child such as the body of the response.
+func (s *Step) WrapAssertionError(err error, query string) error {
+	if s.positions == nil {
+		return err
+	}
+	keys := queryKeys(query)
+	if _, ok := s.positions.lookup(append([]string{"expect"}, keys...)...); ok {
+		return s.WrapError(err, append([]string{"expect"}, keys...)...)
+	}
+	if expect, ok := s.positions.lookup("expect"); ok && resolveAlias(expect).Kind == yaml3.MappingNode {
+		expect = resolveAlias(expect)
+		for i := 0; i+1 < len(expect.Content); i += 2 {
+			path := append([]string{"expect", expect.Content[i].Value}, keys...)
+			if _, ok := s.positions.lookup(path...); ok {
+				return s.WrapError(err, path...)
+			}
+		}
+	}
+	return s.WrapError(err, "expect")
 }
 
 type stepUnmarshaller Step
@@ -44,7 +172,11 @@ func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	if err := unmarshal((*stepUnmarshaller)(s)); err != nil {
 		return err
 	}
+	return s.validate()
+}
 
+// validate builds the request and expect of s with the protocol and checks the combination of the fields.
+func (s *Step) validate() error {
 	p := protocol.Get(s.Protocol)
 	if p == nil {
 		if s.Request.unmarshal != nil || s.Expect.unmarshal != nil {
@@ -66,6 +198,127 @@ func (s *Step) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		s.Expect.AssertionBuilder = builder
 	}
 
+	if s.Parallel != nil {
+		if err := s.validateParallel(); err != nil {
+			return err
+		}
+	}
+
+	if s.Repeat < 0 {
+		return errors.Errorf("repeat must not be negative but got %d", s.Repeat)
+	}
+	if s.Repeat > 0 && s.Foreach != nil {
+		return errors.New("either repeat or foreach can be used")
+	}
+
+	if s.Wait != 0 || s.WaitFor != nil {
+		if err := s.validateWait(); err != nil {
+			return err
+		}
+	}
+
+	if s.Retry != nil {
+		if s.Include != nil || s.Ref != "" {
+			return errors.New("retry can be used with only request")
+		}
+		if err := s.Retry.validate(); err != nil {
+			return errors.Wrap(err, "invalid retry")
+		}
+	}
+
+	return nil
+}
+
+// validateParallel checks that s has only the fields which can be used with parallel steps.
+func (s *Step) validateParallel() error {
+	var fields []string
+	if s.Vars != nil {
+		fields = append(fields, "vars")
+	}
+	if s.Protocol != "" || s.Request.unmarshal != nil || s.Expect.unmarshal != nil {
+		fields = append(fields, "request")
+	}
+	if s.Include != nil {
+		fields = append(fields, "include")
+	}
+	if s.Ref != "" {
+		fields = append(fields, "ref")
+	}
+	if s.Bind.Vars != nil {
+		fields = append(fields, "bind")
+	}
+	if s.Retry != nil {
+		fields = append(fields, "retry")
+	}
+	if s.Wait != 0 || s.WaitFor != nil {
+		fields = append(fields, "wait")
+	}
+	if s.Repeat > 0 || s.Foreach != nil {
+		fields = append(fields, "loop")
+	}
+	if len(fields) > 0 {
+		return errors.Errorf("parallel can not be used with %s", strings.Join(fields, ", "))
+	}
+	if len(s.Parallel) == 0 {
+		return errors.New("parallel must have steps")
+	}
+	return nil
+}
+
+// validateWait checks that s is a valid wait or waitFor step.
+func (s *Step) validateWait() error {
+	name := "wait"
+	if s.WaitFor != nil {
+		if s.Wait != 0 {
+			return errors.New("either wait or waitFor can be used")
+		}
+		name = "waitFor"
+		if err := s.WaitFor.validate(); err != nil {
+			return errors.Wrap(err, "invalid waitFor")
+		}
+	} else if s.Wait < 0 {
+		return errors.Errorf("wait must not be negative but got %s", s.Wait)
+	}
+	var fields []string
+	if s.Protocol != "" || s.Request.unmarshal != nil || s.Expect.unmarshal != nil {
+		fields = append(fields, "request")
+	}
+	if s.Include != nil {
+		fields = append(fields, "include")
+	}
+	if s.Ref != "" {
+		fields = append(fields, "ref")
+	}
+	if s.Retry != nil {
+		fields = append(fields, "retry")
+	}
+	if len(fields) > 0 {
+		return errors.Errorf("%s can not be used with %s", name, strings.Join(fields, ", "))
+	}
+	return nil
+}
+
+// WaitFor represents a condition to wait for.
+// The condition is evaluated repeatedly at the interval until it becomes true or the timeout is exceeded.
+type WaitFor struct {
+	// Condition is a template which is evaluated as a boolean like "if".
+	Condition string `yaml:"condition"`
+	// Interval is the duration between evaluations. The default is 1s.
+	Interval time.Duration `yaml:"interval"`
+	// Timeout is the deadline to give up waiting.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+func (w *WaitFor) validate() error {
+	if w.Condition == "" {
+		return errors.New("condition must be specified")
+	}
+	if w.Interval < 0 {
+		return errors.Errorf("interval must not be negative but got %s", w.Interval)
+	}
+	if w.Timeout <= 0 {
+		return errors.New("timeout must be specified")
+	}
 	return nil
 }
 
@@ -115,3 +368,37 @@ func (e *Expect) UnmarshalYAML(unmarshal func(interface{}) error) error {
 type Bind struct {
 	Vars map[string]interface{} `yaml:"vars"`
 }
+
+// RetryPolicy represents a retry policy of a step.
+// The request is sent again until the expectations pass or the limits are exceeded.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts including the first one.
+	MaxAttempts int `yaml:"maxAttempts"`
+	// Interval is the wait time before the first retry. The default is 1s.
+	Interval time.Duration `yaml:"interval"`
+	// MaxInterval is the upper limit of the wait time.
+	MaxInterval time.Duration `yaml:"maxInterval"`
+	// Multiplier is the factor to increase the wait time for each retry. The default is 1.
+	Multiplier float64 `yaml:"multiplier"`
+	// MaxElapsedTime is the maximum time to retry.
+	MaxElapsedTime time.Duration `yaml:"maxElapsedTime"`
+}
+
+func (p *RetryPolicy) validate() error {
+	if p.MaxAttempts < 0 {
+		return errors.Errorf("maxAttempts must not be negative but got %d", p.MaxAttempts)
+	}
+	if p.MaxAttempts == 0 && p.MaxElapsedTime <= 0 {
+		return errors.New("maxAttempts or maxElapsedTime must be specified")
+	}
+	if p.Interval < 0 {
+		return errors.Errorf("interval must not be negative but got %s", p.Interval)
+	}
+	if p.MaxInterval < 0 {
+		return errors.Errorf("maxInterval must not be negative but got %s", p.MaxInterval)
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		return errors.Errorf("multiplier must be greater than or equal to 1 but got %v", p.Multiplier)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/zoncoen/scenarigo/protocol"
+	"github.com/zoncoen/yaml"
+)
+
+func TestStep_UnmarshalYAML(t *testing.T) {
+	p := &testProtocol{name: "test"}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	t.Run("success", func(t *testing.T) {
+		in := `
+title: scenario
+steps:
+- title: parallel
+  parallel:
+  - title: GET /
+    protocol: test
+    request: request
+`
+		var s Scenario
+		if err := yaml.Unmarshal([]byte(in), &s); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got, expect := len(s.Steps), 1; got != expect {
+			t.Fatalf("expected %d steps but got %d", expect, got)
+		}
+		if got, expect := len(s.Steps[0].Parallel), 1; got != expect {
+			t.Fatalf("expected %d parallel steps but got %d", expect, got)
+		}
+		if got, expect := s.Steps[0].Parallel[0].Title, "GET /"; got != expect {
+			t.Errorf("expected title %q but got %q", expect, got)
+		}
+		if diff := cmp.Diff("request", p.request); diff != "" {
+			t.Errorf("request differs (-want +got):\n%s", diff)
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			in     string
+			expect string
+		}{
+			"unknown protocol": {
+				in: `
+title: GET /
+protocol: unknown
+request: request
+`,
+				expect: "unknown protocol: unknown",
+			},
+			"invalid retry": {
+				in: `
+title: GET /
+protocol: test
+retry: {}
+`,
+				expect: "invalid retry: maxAttempts or maxElapsedTime must be specified",
+			},
+			"invalid parallel step": {
+				in: `
+title: parallel
+parallel:
+- title: wait
+  wait: -1s
+`,
+				expect: "wait must not be negative but got -1s",
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				var s Step
+				err := yaml.Unmarshal([]byte(test.in), &s)
+				if err == nil {
+					t.Fatal("expected error but no error")
+				}
+				if diff := cmp.Diff(test.expect, err.Error()); diff != "" {
+					t.Errorf("error differs (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/yaml"
+)
+
+// LoadVarsFile loads the variables from the YAML, JSON or .env file.
+// The format is determined by the file extension: ".yaml", ".yml", ".json" or ".env".
+// Files whose names start with ".env" such as ".env.staging" are also loaded as .env files.
+func LoadVarsFile(path string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to load vars file "%s"`, path)
+	}
+	var vars map[string]interface{}
+	switch {
+	case isDotEnvFile(path):
+		vars, err = parseDotEnv(b)
+	case isYAMLOrJSONFile(path):
+		// JSON is a subset of YAML
+		err = yaml.Unmarshal(b, &vars)
+	default:
+		err = errors.New("unknown file format")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, `failed to load vars file "%s"`, path)
+	}
+	if vars == nil {
+		vars = map[string]interface{}{}
+	}
+	return vars, nil
+}
+
+// LoadVarsFiles loads the variables from the files.
+// The variables of the later files override the earlier ones.
+func LoadVarsFiles(paths ...string) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	for _, path := range paths {
+		v, err := LoadVarsFile(path)
+		if err != nil {
+			return nil, err
+		}
+		vars = MergeVars(vars, v)
+	}
+	return vars, nil
+}
+
+// MergeVars returns a new map which has the variables of all vars.
+// The variables of the later maps override the earlier ones.
+func MergeVars(vars ...map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+	for _, v := range vars {
+		for k, val := range v {
+			merged[k] = val
+		}
+	}
+	return merged
+}
+
+func isDotEnvFile(path string) bool {
+	return filepath.Ext(path) == ".env" || strings.HasPrefix(filepath.Base(path), ".env")
+}
+
+func isYAMLOrJSONFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml", ".json":
+		return true
+	}
+	return false
+}
+
+// parseDotEnv parses the lines such as "KEY=value" of .env files.
+// Blank lines and lines starting with "#" are ignored, and the "export" prefix is allowed.
+// Values can be quoted by double or single quotes.
+func parseDotEnv(b []byte) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+	s := bufio.NewScanner(bytes.NewReader(b))
+	for i := 1; s.Scan(); i++ {
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		idx := strings.Index(line, "=")
+		if idx < 0 {
+			return nil, errors.Errorf(`line %d: expected "KEY=value" but got "%s"`, i, line)
+		}
+		key, value := strings.TrimSpace(line[:idx]), strings.TrimSpace(line[idx+1:])
+		if key == "" {
+			return nil, errors.Errorf("line %d: empty key", i)
+		}
+		value, err := unquoteDotEnvValue(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", i)
+		}
+		vars[key] = value
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+func unquoteDotEnvValue(v string) (string, error) {
+	if len(v) >= 2 {
+		switch {
+		case v[0] == '"' && v[len(v)-1] == '"':
+			s, err := strconv.Unquote(v)
+			if err != nil {
+				return "", errors.Errorf("invalid quoted value %s", v)
+			}
+			return s, nil
+		case v[0] == '\'' && v[len(v)-1] == '\'':
+			return v[1 : len(v)-1], nil
+		}
+	}
+	// strip the inline comment of unquoted values
+	if idx := strings.Index(v, " #"); idx >= 0 {
+		v = strings.TrimSpace(v[:idx])
+	}
+	return v, nil
+}
@@ -0,0 +1,120 @@
+package schema
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoadVarsFile(t *testing.T) {
+	t.Run("success", func(t *testing.T) {
+		tests := map[string]struct {
+			path   string
+			expect map[string]interface{}
+		}{
+			"yaml": {
+				path: "testdata/vars/vars.yaml",
+				expect: map[string]interface{}{
+					"endpoint": "http://localhost:8080",
+					"port":     8080,
+					"user": map[interface{}]interface{}{
+						"name": "alice",
+					},
+				},
+			},
+			"json": {
+				path: "testdata/vars/vars.json",
+				expect: map[string]interface{}{
+					"endpoint": "http://localhost:8080",
+					"port":     8080,
+					"user": map[interface{}]interface{}{
+						"name": "alice",
+					},
+				},
+			},
+			"env": {
+				path: "testdata/vars/vars.env",
+				expect: map[string]interface{}{
+					"ENDPOINT":      "http://localhost:8080",
+					"PORT":          "8080",
+					"DOUBLE_QUOTED": "hello\nworld",
+					"SINGLE_QUOTED": "hello # world",
+					"UNQUOTED":      "hello",
+					"EMPTY":         "",
+				},
+			},
+			"dot env": {
+				path: "testdata/config/vars/.env.staging",
+				expect: map[string]interface{}{
+					"endpoint": "https://env.staging.example.com",
+					"token":    "staging-token",
+				},
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				got, err := LoadVarsFile(test.path)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				if diff := cmp.Diff(test.expect, got); diff != "" {
+					t.Errorf("vars differs (-want +got):\n%s", diff)
+				}
+			})
+		}
+	})
+	t.Run("failure", func(t *testing.T) {
+		tests := map[string]struct {
+			path   string
+			expect string
+		}{
+			"not found": {
+				path:   "testdata/vars/notfound.yaml",
+				expect: `failed to load vars file "testdata/vars/notfound.yaml": open testdata/vars/notfound.yaml: no such file or directory`,
+			},
+			"invalid yaml": {
+				path:   "testdata/vars/invalid.yaml",
+				expect: `failed to load vars file "testdata/vars/invalid.yaml": yaml: line 1: did not find expected node content`,
+			},
+			"invalid env": {
+				path:   "testdata/vars/invalid.env",
+				expect: `failed to load vars file "testdata/vars/invalid.env": line 1: expected "KEY=value" but got "ENDPOINT"`,
+			},
+			"unknown format": {
+				path:   "testdata/vars/vars.toml",
+				expect: `failed to load vars file "testdata/vars/vars.toml": unknown file format`,
+			},
+		}
+		for name, test := range tests {
+			test := test
+			t.Run(name, func(t *testing.T) {
+				_, err := LoadVarsFile(test.path)
+				if err == nil {
+					t.Fatal("expected error but no error")
+				}
+				if got, expect := err.Error(), test.expect; got != expect {
+					t.Errorf("expected %q but got %q", expect, got)
+				}
+			})
+		}
+	})
+}
+
+func TestLoadVarsFiles(t *testing.T) {
+	got, err := LoadVarsFiles("testdata/vars/vars.yaml", "testdata/config/vars/.env.staging")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expect := map[string]interface{}{
+		"endpoint": "https://env.staging.example.com",
+		"port":     8080,
+		"token":    "staging-token",
+		"user": map[interface{}]interface{}{
+			"name": "alice",
+		},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("vars differs (-want +got):\n%s", diff)
+	}
+}
@@ -1,7 +1,11 @@
 package scenarigo
 
 import (
+	gocontext "context"
+	"time"
+
 	"github.com/k0kubun/pp"
+	"github.com/pkg/errors"
 	"github.com/zoncoen/scenarigo/assert"
 	"github.com/zoncoen/scenarigo/context"
 	"github.com/zoncoen/scenarigo/plugin"
@@ -13,83 +17,196 @@ func init() {
 	pp.ColoringEnabled = false
 }
 
-func dumpReqResp(ctx *context.Context) func() {
-	return func() {
-		if req := ctx.Request(); req != nil {
-			if b, err := yaml.Marshal(req); err == nil {
-				ctx.Reporter().Logf("request:\n%s", string(b))
-			} else {
-				ctx.Reporter().Logf("request:\n%s", pp.Sprint(req))
-			}
+// dumpReqResp adds the request and response of the step to log for debugging.
+// The secrets are masked only here and when the result is marshaled not to mask the passed steps.
+func dumpReqResp(ctx *context.Context, result *StepResult) {
+	if req := result.MaskedRequest(); req != nil {
+		if b, err := yaml.Marshal(req); err == nil {
+			ctx.Reporter().Logf("request:\n%s", string(b))
+		} else {
+			ctx.Reporter().Logf("request:\n%s", pp.Sprint(req))
 		}
-		if resp := ctx.Response(); resp != nil {
-			if b, err := yaml.Marshal(resp); err == nil {
-				ctx.Reporter().Logf("response:\n%s", string(b))
-			} else {
-				ctx.Reporter().Logf("response:\n%s", pp.Sprint(resp))
-			}
+	}
+	if resp := result.MaskedResponse(); resp != nil {
+		if b, err := yaml.Marshal(resp); err == nil {
+			ctx.Reporter().Logf("response:\n%s", string(b))
+		} else {
+			ctx.Reporter().Logf("response:\n%s", pp.Sprint(resp))
 		}
 	}
 }
 
-func runStep(ctx *context.Context, s *schema.Step, debug *func()) *context.Context {
-	// set the function which adds the request and response to log for debugging
+func runStep(ctx *context.Context, s *schema.Step, result *StepResult) *context.Context {
+	// record the request and response even if the step failed
 	defer func() {
-		*debug = dumpReqResp(ctx)
+		result.Request = ctx.Request()
+		result.Response = ctx.Response()
+		result.masker = ctx.Masker()
 	}()
 
 	if s.Vars != nil {
 		vars, err := ctx.ExecuteTemplate(s.Vars)
 		if err != nil {
-			ctx.Reporter().Fatalf("invalid vars: %s", err)
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "invalid vars"), "vars"))
 		}
 		ctx = ctx.WithVars(vars)
 	}
 
-	if s.Include != "" {
-		scenarios, err := schema.LoadScenarios(s.Include)
-		if err != nil {
-			ctx.Reporter().Fatalf(`failed to include "%s" as step: %s`, s.Include, err)
-		}
-		if len(scenarios) != 1 {
-			ctx.Reporter().Fatalf(`failed to include "%s" as step: must be a scenario`, s.Include)
+	timeout := s.Timeout
+	if timeout == 0 && s.Include == nil {
+		// the default timeout is applied to each step of the included scenario
+		timeout = ctx.StepTimeout()
+	}
+	if timeout > 0 {
+		var cancel gocontext.CancelFunc
+		ctx, cancel = withTimeout(ctx, "step", timeout)
+		defer cancel()
+	}
+
+	if s.Include != nil {
+		ctx = runInclude(ctx, s, result)
+		if ctx.Reporter().Failed() {
+			ctx.Reporter().FailNow()
 		}
-		ctx = runScenario(ctx, scenarios[0])
 		return ctx
 	}
 	if s.Ref != "" {
 		x, err := ctx.ExecuteTemplate(s.Ref)
 		if err != nil {
-			ctx.Reporter().Fatalf(`failed to reference "%s" as step: %s`, s.Ref, err)
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrapf(err, `failed to reference "%s" as step`, s.Ref), "ref"))
 		}
 		stp, ok := x.(plugin.Step)
 		if !ok {
-			ctx.Reporter().Fatalf(`failed to reference "%s" as step: not implement plugin.Step interface`, s.Ref)
+			ctx.Reporter().Fatal(s.WrapError(errors.Errorf(`failed to reference "%s" as step: not implement plugin.Step interface`, s.Ref), "ref"))
 		}
 		ctx = stp.Run(ctx, s)
 		return ctx
 	}
 
-	newCtx, resp, err := s.Request.Invoke(ctx)
-	if err != nil {
-		ctx.Reporter().Fatal(err)
+	if s.Wait > 0 {
+		return wait(ctx, s)
+	}
+	if s.WaitFor != nil {
+		return waitFor(ctx, s)
 	}
-	ctx = newCtx
 
-	assertion, err := s.Expect.Build(ctx)
+	ctx, attempts, err := retry(ctx, s.Retry, func(ctx *context.Context) (*context.Context, error) {
+		return invokeAndAssert(ctx, s)
+	})
 	if err != nil {
-		ctx.Reporter().Fatal(err)
-	}
-	if err := assertion.Assert(resp); err != nil {
+		if attempts > 1 {
+			ctx.Reporter().Logf("gave up after %d attempts", attempts)
+		}
 		if assertErr, ok := err.(*assert.Error); ok {
 			for _, err := range assertErr.Errors {
-				ctx.Reporter().Error(err)
+				ctx.Reporter().Error(wrapAssertionError(s, err))
 			}
 			ctx.Reporter().FailNow()
-		} else {
-			ctx.Reporter().Fatal(err)
 		}
+		ctx.Reporter().Fatal(err)
+	}
+
+	return ctx
+}
+
+// runInclude runs the included scenario.
+// If the scenario is included by a path, the returned context has all variables which are defined by it.
+// Otherwise, only the return values of the include are added to the returned context.
+// The returned context also has the last request and response of the included scenario even if it failed.
+func runInclude(ctx *context.Context, s *schema.Step, result *StepResult) *context.Context {
+	inc := s.Include
+	scenarios, err := schema.LoadScenarios(inc.Path)
+	if err != nil {
+		ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "failed to include as step"), "include"))
+	}
+	scn, err := inc.Select(scenarios)
+	if err != nil {
+		ctx.Reporter().Fatal(s.WrapError(errors.Wrapf(err, `failed to include "%s" as step`, inc.Path), "include", "scenario"))
+	}
+	// report the results of the included steps
+	result.Include = newScenarioResult(scn, nil)
+	start := time.Now()
+	defer func() {
+		result.Include.Status = statusOf(ctx.Reporter())
+		result.Include.Duration = time.Since(start)
+	}()
+	if inc.IsShared() {
+		return withLastExchange(runScenario(ctx, scn, result.Include), result.Include)
+	}
+
+	// the included scenario can refer only the arguments
+	incCtx := ctx.WithoutVars()
+	if inc.Vars != nil {
+		vars, err := ctx.ExecuteTemplate(inc.Vars)
+		if err != nil {
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "invalid include vars"), "include", "vars"))
+		}
+		incCtx = incCtx.WithVars(vars)
 	}
+	incCtx = runScenario(incCtx, scn, result.Include)
+	ctx = withLastExchange(ctx, result.Include)
+	if ctx.Reporter().Failed() {
+		return ctx
+	}
+	if inc.Bind != nil {
+		vars, err := incCtx.ExecuteTemplate(inc.Bind)
+		if err != nil {
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "invalid include bind"), "include", "bind"))
+		}
+		ctx = ctx.WithVars(vars)
+	}
+	return ctx
+}
 
+// withLastExchange returns a copy of ctx which has the last request and response of the steps in result for debugging.
+// It returns ctx as it is if no steps have sent requests.
+func withLastExchange(ctx *context.Context, result *ScenarioResult) *context.Context {
+	for _, steps := range [][]*StepResult{result.Teardown, result.Steps} {
+		for i := len(steps) - 1; i >= 0; i-- {
+			if step := steps[i]; step.Request != nil || step.Response != nil {
+				return ctx.WithRequest(step.Request).WithResponse(step.Response)
+			}
+		}
+	}
 	return ctx
 }
+
+// invokeAndAssert sends the request and asserts the response.
+// The returned context is not nil even if failed to keep the request for debugging.
+func invokeAndAssert(ctx *context.Context, s *schema.Step) (*context.Context, error) {
+	newCtx, resp, err := s.Request.Invoke(ctx)
+	if newCtx != nil {
+		ctx = newCtx
+	}
+	if err != nil {
+		return ctx, s.WrapError(timeoutError(ctx, err), "request")
+	}
+
+	assertion, err := s.Expect.Build(ctx)
+	if err != nil {
+		return ctx, s.WrapError(err, "expect")
+	}
+	if err := assertion.Assert(resp); err != nil {
+		if _, ok := err.(*assert.Error); ok {
+			// the errors are reported with the positions one by one
+			return ctx, err
+		}
+		return ctx, wrapAssertionError(s, err)
+	}
+	return ctx, nil
+}
+
+// wrapAssertionError annotates err with the position of the expected value.
+func wrapAssertionError(s *schema.Step, err error) error {
+	for e := err; e != nil; {
+		if queryErr, ok := e.(*assert.QueryError); ok {
+			return s.WrapAssertionError(err, queryErr.Query)
+		}
+		cause, ok := e.(interface{ Cause() error })
+		if !ok {
+			break
+		}
+		e = cause.Cause()
+	}
+	return s.WrapError(err, "expect")
+}
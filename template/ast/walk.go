@@ -0,0 +1,33 @@
+package ast
+
+// Inspect traverses an AST in depth-first order: It starts by calling f(node); node must not be nil.
+// If f returns true, Inspect invokes f recursively for each of the non-nil children of node.
+func Inspect(node Node, f func(Node) bool) {
+	if !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *BinaryExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Y, f)
+	case *ParameterExpr:
+		inspectExpr(n.X, f)
+	case *SelectorExpr:
+		inspectExpr(n.X, f)
+		Inspect(n.Sel, f)
+	case *IndexExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Index, f)
+	case *CallExpr:
+		inspectExpr(n.Fun, f)
+		for _, arg := range n.Args {
+			inspectExpr(arg, f)
+		}
+	}
+}
+
+func inspectExpr(e Expr, f func(Node) bool) {
+	if e != nil {
+		Inspect(e, f)
+	}
+}
@@ -35,6 +35,11 @@ func New(str string) (*Template, error) {
 	}, nil
 }
 
+// Root returns the root node of the parsed template.
+func (t *Template) Root() ast.Node {
+	return t.expr
+}
+
 // Execute applies a parsed template to the specified data.
 func (t *Template) Execute(data interface{}) (interface{}, error) {
 	return t.executeExpr(t.expr, data)
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"github.com/zoncoen/scenarigo/plugin"
+)
+
+func Setup(ctx *plugin.Context) *plugin.Context {
+	return ctx.WithVars(map[string]interface{}{
+		"token": "secret",
+	})
+}
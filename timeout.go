@@ -0,0 +1,50 @@
+package scenarigo
+
+import (
+	gocontext "context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/context"
+)
+
+type keyTimeout struct{}
+
+// timeout represents a timeout of the request context.
+type timeout struct {
+	target   string
+	duration time.Duration
+	ctx      gocontext.Context
+	parent   *timeout
+}
+
+// withTimeout returns a copy of ctx whose request context is canceled after d.
+// The target is used to describe the timeout in error messages.
+func withTimeout(ctx *context.Context, target string, d time.Duration) (*context.Context, gocontext.CancelFunc) {
+	parent, _ := ctx.RequestContext().Value(keyTimeout{}).(*timeout)
+	reqCtx, cancel := gocontext.WithTimeout(ctx.RequestContext(), d)
+	t := &timeout{
+		target:   target,
+		duration: d,
+		ctx:      reqCtx,
+		parent:   parent,
+	}
+	return ctx.WithRequestContext(gocontext.WithValue(reqCtx, keyTimeout{}, t)), cancel
+}
+
+// timeoutError annotates err with the timeout which is exceeded.
+// It returns err as it is if the request context of ctx has not been timed out.
+func timeoutError(ctx *context.Context, err error) error {
+	var exceeded *timeout
+	t, _ := ctx.RequestContext().Value(keyTimeout{}).(*timeout)
+	for ; t != nil; t = t.parent {
+		// the outermost one is the cause because the inner contexts are canceled together
+		if t.ctx.Err() == gocontext.DeadlineExceeded {
+			exceeded = t
+		}
+	}
+	if exceeded == nil {
+		return err
+	}
+	return errors.Wrapf(err, "%s timed out after %s", exceeded.target, exceeded.duration)
+}
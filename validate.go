@@ -0,0 +1,272 @@
+package scenarigo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/protocol"
+	"github.com/zoncoen/scenarigo/schema"
+	"github.com/zoncoen/scenarigo/template"
+	"github.com/zoncoen/scenarigo/template/ast"
+)
+
+// maxIncludeDepth is the limit of nested includes to detect circular includes.
+const maxIncludeDepth = 32
+
+// Validate checks the scenario files statically without sending any requests.
+// It reports YAML errors, invalid templates, undefined variables, missing include files and plugins, and unknown protocols.
+// The returned error is a *multierror.Error which has an error for each problem.
+// The errors of the scenarios are *schema.Error which have the positions in the files.
+func (r *Runner) Validate() error {
+	var errs *multierror.Error
+	v := &validator{runner: r}
+	vars := v.validateHooks("setup", r.setup, varSet{}.with(r.vars))
+	errs = multierror.Append(errs, v.errs...)
+	for _, f := range r.scenarioFiles {
+		scns, err := schema.LoadScenarios(f)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		for _, scn := range r.filterScenarios(f, scns) {
+			v := &validator{runner: r}
+			v.validateScenario(scn.Title, scn, vars, 0)
+			errs = multierror.Append(errs, v.errs...)
+		}
+	}
+	v = &validator{runner: r}
+	v.validateHooks("teardown", r.teardown, vars)
+	errs = multierror.Append(errs, v.errs...)
+	return errs.ErrorOrNil()
+}
+
+type validator struct {
+	runner *Runner
+	errs   []error
+}
+
+// errorf adds an error at pos. The path is the titles of the scenario and steps such as "scenario/step".
+func (v *validator) errorf(pos schema.Position, path, format string, args ...interface{}) {
+	v.errs = append(v.errs, &schema.Error{
+		Pos: pos,
+		Err: errors.Errorf("%s: %s", path, fmt.Sprintf(format, args...)),
+	})
+}
+
+// validateHooks validates the scenarios of hooks and returns the variables which are defined by them.
+func (v *validator) validateHooks(name string, hooks []*schema.Hook, vars varSet) varSet {
+	for _, h := range hooks {
+		if h.Plugin != "" {
+			// the plugin may add any variables
+			vars = nil
+			continue
+		}
+		scns, err := schema.LoadScenarios(h.Scenario)
+		if err != nil {
+			v.errs = append(v.errs, errors.Wrapf(err, "failed to load %s hook", name))
+			continue
+		}
+		for _, scn := range scns {
+			vars = v.validateScenario(scn.Title, scn, vars, 0)
+		}
+	}
+	return vars
+}
+
+// validateScenario validates scn and returns the variables which are defined at the end of scn.
+func (v *validator) validateScenario(path string, scn *schema.Scenario, vars varSet, depth int) varSet {
+	for name, p := range scn.Plugins {
+		if root := v.runner.pluginDir; root != nil {
+			p = filepath.Join(*root, p)
+		}
+		if _, err := os.Stat(p); err != nil {
+			v.errorf(scn.Position("plugins", name), path, `plugin "%s" not found: %s`, name, p)
+		}
+	}
+	paramVars := vars
+	for _, params := range scn.ParameterSets() {
+		v.validateTemplates(scn.Position("parameters"), path, "parameters", params, vars)
+		paramVars = paramVars.with(params)
+	}
+	vars = paramVars
+	v.validateTemplates(scn.Position("vars"), path, "vars", scn.Vars, vars)
+	vars = vars.with(scn.Vars)
+
+	for _, step := range scn.Steps {
+		bound := v.validateStep(fmt.Sprintf("%s/%s", path, step.Title), scn, step, vars, depth)
+		vars = vars.with(bound)
+	}
+	for _, step := range scn.Teardown {
+		bound := v.validateStep(fmt.Sprintf("%s/%s", path, step.Title), scn, step, vars, depth)
+		vars = vars.with(bound)
+	}
+	return vars
+}
+
+// validateStep validates step and returns the variables which are bound to the scenario.
+func (v *validator) validateStep(path string, scn *schema.Scenario, step *schema.Step, vars varSet, depth int) map[string]interface{} {
+	v.validateTemplates(step.Position("if"), path, "if", step.If, vars)
+	if step.Parallel != nil {
+		// the parallel steps can not refer to the values bound by each other
+		bound := map[string]interface{}{}
+		for _, child := range step.Parallel {
+			for k, x := range v.validateStep(fmt.Sprintf("%s/%s", path, child.Title), scn, child, vars, depth) {
+				bound[k] = x
+			}
+		}
+		return bound
+	}
+	if step.Repeat > 0 || step.Foreach != nil {
+		v.validateTemplates(step.Position("foreach"), path, "foreach", step.Foreach, vars)
+		vars = vars.with(map[string]interface{}{"loop": nil})
+	}
+	v.validateTemplates(step.Position("vars"), path, "vars", step.Vars, vars)
+	vars = vars.with(step.Vars)
+
+	if step.Protocol != "" && protocol.Get(step.Protocol) == nil {
+		v.errorf(step.Position("protocol"), path, "unknown protocol: %s", step.Protocol)
+	}
+	if step.Include != nil {
+		vars = v.validateInclude(path, filepath.Dir(scn.Filepath()), step, vars, depth)
+	}
+	if step.Ref != "" {
+		v.validateTemplates(step.Position("ref"), path, "ref", step.Ref, vars)
+	}
+	if step.WaitFor != nil {
+		v.validateTemplates(step.Position("waitFor", "condition"), path, "waitFor", step.WaitFor.Condition, vars)
+	}
+	if step.Request.Invoker != nil {
+		v.validateTemplates(step.Position("request"), path, "request", step.Request.Invoker, vars)
+	}
+	if step.Expect.AssertionBuilder != nil {
+		v.validateTemplates(step.Position("expect"), path, "expect", step.Expect.AssertionBuilder, vars)
+	}
+	if step.Ref != "" {
+		// the plugin may add any variables
+		vars = nil
+	}
+	v.validateTemplates(step.Position("bind"), path, "bind", step.Bind.Vars, vars)
+	return step.Bind.Vars
+}
+
+// validateInclude validates the scenario included by step and returns the variables which are visible after the include.
+func (v *validator) validateInclude(path, dir string, step *schema.Step, vars varSet, depth int) varSet {
+	inc := step.Include
+	include := filepath.Join(dir, inc.Path)
+	if depth >= maxIncludeDepth {
+		v.errorf(step.Position("include"), path, `failed to include "%s": too many nested includes`, include)
+		return vars
+	}
+	scns, err := schema.LoadScenarios(include)
+	if err != nil {
+		v.errorf(step.Position("include"), path, "failed to include: %s", err)
+		return vars
+	}
+	scn, err := inc.Select(scns)
+	if err != nil {
+		v.errorf(step.Position("include"), path, `failed to include "%s": %s`, include, err)
+		return vars
+	}
+	if inc.IsShared() {
+		// the included scenario shares the variables with the caller
+		return v.validateScenario(scn.Title, scn, vars, depth+1)
+	}
+	v.validateTemplates(step.Position("include", "vars"), path, "include vars", inc.Vars, vars)
+	// the included scenario can refer only the arguments
+	incVars := v.validateScenario(scn.Title, scn, varSet{}.with(inc.Vars), depth+1)
+	v.validateTemplates(step.Position("include", "bind"), path, "include bind", inc.Bind, incVars)
+	return vars.with(inc.Bind)
+}
+
+// validateTemplates parses all template strings in x and checks the variables which they refer.
+// The errors are reported at pos of the field. If vars is nil, the variables are not checked.
+func (v *validator) validateTemplates(pos schema.Position, path, field string, x interface{}, vars varSet) {
+	walkStrings(reflect.ValueOf(x), func(s string) {
+		tmpl, err := template.New(s)
+		if err != nil {
+			v.errorf(pos, path, "invalid %s: %s", field, err)
+			return
+		}
+		for _, name := range referredVars(tmpl.Root()) {
+			if vars != nil && !vars[name] {
+				v.errorf(pos, path, `invalid %s: "%s" refers undefined variable "vars.%s"`, field, s, name)
+			}
+		}
+	})
+}
+
+// referredVars returns the names of variables which are referred as "vars.name".
+func referredVars(node ast.Node) []string {
+	var names []string
+	if node == nil {
+		return names
+	}
+	ast.Inspect(node, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if x, ok := sel.X.(*ast.Ident); ok && x.Name == "vars" {
+				names = append(names, sel.Sel.Name)
+				return false
+			}
+		}
+		return true
+	})
+	return names
+}
+
+// walkStrings calls f for each string in v recursively.
+func walkStrings(v reflect.Value, f func(string)) {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if !v.IsNil() {
+			walkStrings(v.Elem(), f)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			walkStrings(v.MapIndex(k), f)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			walkStrings(v.Index(i), f)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue // unexported
+			}
+			walkStrings(v.Field(i), f)
+		}
+	case reflect.String:
+		if strings.Contains(v.String(), "{{") {
+			f(v.String())
+		}
+	}
+}
+
+// varSet represents the names of defined variables.
+type varSet map[string]bool
+
+// with returns a copy of s with the names of vars.
+// If s is nil, it returns nil because any variables can be defined.
+func (s varSet) with(vars map[string]interface{}) varSet {
+	if s == nil {
+		return nil
+	}
+	c := make(varSet, len(s)+len(vars))
+	for k := range s {
+		c[k] = true
+	}
+	for k := range vars {
+		c[k] = true
+	}
+	return c
+}
@@ -0,0 +1,87 @@
+package scenarigo
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/hashicorp/go-multierror"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/protocol"
+)
+
+type templateRequest struct {
+	Request interface{}
+}
+
+func (r *templateRequest) Invoke(ctx *context.Context) (*context.Context, interface{}, error) {
+	return ctx, nil, nil
+}
+
+func TestRunner_Validate(t *testing.T) {
+	p := &testProtocol{
+		name: "test",
+		requstUnmarshaller: func(f func(interface{}) error) (protocol.Invoker, error) {
+			var req templateRequest
+			if err := f(&req.Request); err != nil {
+				return nil, err
+			}
+			return &req, nil
+		},
+	}
+	protocol.Register(p)
+	defer protocol.Unregister(p.Name())
+
+	tests := map[string]struct {
+		path   string
+		expect []string
+	}{
+		"valid": {
+			path: "testdata/validate/valid.yaml",
+		},
+		"invalid": {
+			path: "testdata/validate/invalid.yaml",
+			expect: []string{
+				"testdata/validate/invalid.yaml:3:12: invalid: plugin \"missing\" not found: missing.so",
+				"testdata/validate/invalid.yaml:9:3: invalid/typo: invalid request: \"{{vars.mesage}}\" refers undefined variable \"vars.mesage\"",
+				"testdata/validate/invalid.yaml:13:3: invalid/syntax error: invalid request: failed to parse \"{{vars.message\": col 15: expected 'rdbrace', found 'EOF'",
+				"testdata/validate/invalid.yaml:22:3: invalid/step vars are not visible from following steps: invalid request: \"{{vars.local}}\" refers undefined variable \"vars.local\"",
+				"testdata/validate/invalid.yaml:25:13: invalid/unknown protocol: unknown protocol: unknown",
+				"testdata/validate/invalid.yaml:27:12: invalid/include not found: failed to include: open testdata/validate/notfound.yaml: no such file or directory",
+				"testdata/validate/invalid.yaml:29:3: invalid/included scenario not found: failed to include \"testdata/validate/included.yaml\": scenario \"unknown\" not found",
+				"testdata/validate/invalid.yaml:37:5: invalid/include bind typo: invalid include bind: \"{{vars.sesion}}\" refers undefined variable \"vars.sesion\"",
+				"testdata/validate/invalid.yaml:41:3: invalid/include arguments are not visible from following steps: invalid request: \"{{vars.user}}\" refers undefined variable \"vars.user\"",
+				"testdata/validate/included.yaml:2:1: included: invalid vars: \"session-{{vars.user}}\" refers undefined variable \"vars.user\"",
+				"testdata/validate/included.yaml:7:3: included/create session: invalid request: \"{{vars.user}}\" refers undefined variable \"vars.user\"",
+			},
+		},
+		"broken": {
+			path: "testdata/validate/broken.yaml",
+			expect: []string{
+				"testdata/validate/broken.yaml: failed to decode YAML: yaml: unmarshal errors:\n  line 2: field unknown not found in type schema.Scenario",
+			},
+		},
+	}
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			r, err := NewRunner(WithScenarios(test.path))
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			err = r.Validate()
+			var got []string
+			if err != nil {
+				merr, ok := err.(*multierror.Error)
+				if !ok {
+					t.Fatalf("expected *multierror.Error but got %T", err)
+				}
+				for _, e := range merr.Errors {
+					got = append(got, e.Error())
+				}
+			}
+			if diff := cmp.Diff(test.expect, got); diff != "" {
+				t.Errorf("errors differ (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package scenarigo
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/zoncoen/scenarigo/context"
+	"github.com/zoncoen/scenarigo/schema"
+)
+
+const defaultWaitForInterval = time.Second
+
+// wait sleeps for the duration of s.
+// The step fails if the request context is done before the duration elapses.
+func wait(ctx *context.Context, s *schema.Step) *context.Context {
+	select {
+	case <-time.After(s.Wait):
+	case <-ctx.RequestContext().Done():
+		err := timeoutError(ctx, errors.Wrapf(ctx.RequestContext().Err(), "failed to wait %s", s.Wait))
+		ctx.Reporter().Fatal(s.WrapError(err, "wait"))
+	}
+	return ctx
+}
+
+// waitFor evaluates the condition of s at the interval until it becomes true.
+// The step fails if the timeout is exceeded.
+func waitFor(ctx *context.Context, s *schema.Step) *context.Context {
+	w := s.WaitFor
+	interval := w.Interval
+	if interval == 0 {
+		interval = defaultWaitForInterval
+	}
+	waitCtx, cancel := withTimeout(ctx, "waitFor", w.Timeout)
+	defer cancel()
+	for attempt := 1; ; attempt++ {
+		ok, err := evalCondition(waitCtx, w.Condition)
+		if err != nil {
+			ctx.Reporter().Fatal(s.WrapError(errors.Wrap(err, "invalid condition"), "waitFor", "condition"))
+		}
+		if ok {
+			return ctx
+		}
+		select {
+		case <-time.After(interval):
+		case <-waitCtx.RequestContext().Done():
+			err := timeoutError(waitCtx, errors.Errorf("condition %s is not satisfied after %d attempts", w.Condition, attempt))
+			ctx.Reporter().Fatal(s.WrapError(err, "waitFor", "condition"))
+		}
+	}
+}